@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// combinedLogPattern extracts the request line and status from nginx's combined (or common) log format.
+var combinedLogPattern = regexp.MustCompile(`"(\S+) (\S+) [^"]*" (\d{3}) `)
+
+var downloadPathPattern = regexp.MustCompile(`^/plugins/(stable|testing)/([^/]+)/(?:download|latest\.zip)$`)
+
+type caddyLogEntry struct {
+	Request struct {
+		Method string `json:"method"`
+		URI    string `json:"uri"`
+	} `json:"request"`
+	Status int `json:"status"`
+}
+
+// parseAccessLogLine returns the "<channel>/<InternalName>" key of a successful plugin download request.
+func parseAccessLogLine(line string) (string, bool) {
+	var method, uri string
+	var status int
+	if strings.HasPrefix(line, "{") {
+		var entry caddyLogEntry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			return "", false
+		}
+
+		method, uri, status = entry.Request.Method, entry.Request.URI, entry.Status
+	} else {
+		match := combinedLogPattern.FindStringSubmatch(line)
+		if match == nil {
+			return "", false
+		}
+
+		method, uri = match[1], match[2]
+		status, _ = strconv.Atoi(match[3])
+	}
+
+	if method != "GET" || status < 200 || status >= 400 {
+		return "", false
+	}
+
+	path, _, _ := strings.Cut(uri, "?")
+	match := downloadPathPattern.FindStringSubmatch(path)
+	if match == nil {
+		return "", false
+	}
+
+	return match[1] + "/" + match[2], true
+}
+
+// CountAccessLog counts plugin downloads in an nginx combined or Caddy JSON access log, which may be gzipped.
+func CountAccessLog(content []byte, downloads map[string]int64) error {
+	var reader io.Reader = bytes.NewReader(content)
+	if len(content) > 2 && content[0] == 0x1f && content[1] == 0x8b {
+		gz, err := gzip.NewReader(reader)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+
+		reader = gz
+	}
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if key, ok := parseAccessLogLine(scanner.Text()); ok {
+			downloads[key]++
+		}
+	}
+
+	return scanner.Err()
+}
+
+// AccessLogStatsProvider counts downloads in the access logs of the hosting web server.
+type AccessLogStatsProvider struct {
+	cfg *Config
+}
+
+func (p *AccessLogStatsProvider) FetchDownloadStatistics(map[string]string) (map[string]int64, error) {
+	return FetchAccessLogStatistics(p.cfg)
+}
+
+// FetchAccessLogStatistics counts downloads in the access logs listed in ACCESS_LOGS, which are local glob patterns
+// or s3://bucket/key URLs. An S3 URL ending with a slash reads every object under the prefix.
+func FetchAccessLogStatistics(cfg *Config) (map[string]int64, error) {
+	downloads := map[string]int64{}
+
+	var s3 *S3Client
+	for _, source := range cfg.AccessLogs {
+		if !strings.HasPrefix(source, "s3://") {
+			paths, err := filepath.Glob(source)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, path := range paths {
+				content, err := os.ReadFile(path)
+				if err != nil {
+					return nil, err
+				}
+				if err = CountAccessLog(content, downloads); err != nil {
+					return nil, err
+				}
+			}
+
+			continue
+		}
+
+		if s3 == nil {
+			var err error
+			if s3, err = NewS3Client(cfg); err != nil {
+				return nil, err
+			}
+		}
+
+		bucket, key, err := ParseS3URL(source)
+		if err != nil {
+			return nil, err
+		}
+
+		keys := []string{key}
+		if key == "" || strings.HasSuffix(key, "/") {
+			objects, err := s3.ListObjects(bucket, key)
+			if err != nil {
+				return nil, err
+			}
+
+			keys = nil
+			for _, object := range objects {
+				keys = append(keys, object.Key)
+			}
+		}
+
+		for _, key := range keys {
+			content, err := s3.GetObject(bucket, key)
+			if err != nil {
+				return nil, err
+			}
+			if err = CountAccessLog(content, downloads); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return downloads, nil
+}
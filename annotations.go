@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var annotationMessageEscaper = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+var annotationPropertyEscaper = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+
+// manifestSourcePath returns the manifest file a merged plugin was read from, preferring the testing channel as
+// MergeManifests does.
+func manifestSourcePath(name string) string {
+	for _, environment := range []string{"testing", "stable"} {
+		path, manifest, err := findManifestFile(filepath.Join("plugins", environment, name), name)
+		if err == nil && manifest != nil {
+			return filepath.ToSlash(path)
+		}
+	}
+
+	return ""
+}
+
+// AnnotateManifest prints a GitHub Actions workflow command so that the problem is shown inline on the manifest file
+// of the plugin. It does nothing outside GitHub Actions.
+func AnnotateManifest(level, name, message string) {
+	if os.Getenv("GITHUB_ACTIONS") != "true" {
+		return
+	}
+
+	properties := fmt.Sprintf("title=%s", annotationPropertyEscaper.Replace(name))
+	if path := manifestSourcePath(name); path != "" {
+		properties = fmt.Sprintf("file=%s,%s", annotationPropertyEscaper.Replace(path), properties)
+	}
+
+	fmt.Printf("::%s %s::%s\n", level, properties, annotationMessageEscaper.Replace(message))
+}
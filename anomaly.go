@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"log"
+)
+
+// minDownloadJump ignores jumps of plugins with few downloads, whose counts legitimately multiply after a release.
+// Plugins without a previous count are not checked for jumps either, as counting may have just been enabled.
+const minDownloadJump = 1000
+
+// CheckDownloadAnomalies compares the download counts with the previous master. A drop, or a jump larger than factor
+// times the previous count, usually means that the stats backend was reset or returned partial data. Such counts are
+// reported with mode "warn", or replaced with the previous count with mode "hold".
+func CheckDownloadAnomalies(previous, manifests []*PluginManifest, mode string, factor float64) error {
+	switch mode {
+	case "off":
+		return nil
+	case "warn", "hold":
+	default:
+		return fmt.Errorf("unknown download anomaly mode: %s", mode)
+	}
+
+	counts := map[string]int64{}
+	for _, manifest := range previous {
+		counts[manifest.InternalName] = manifest.DownloadCount
+	}
+
+	for _, manifest := range manifests {
+		old, ok := counts[manifest.InternalName]
+		if !ok {
+			continue
+		}
+
+		var message string
+		switch increase := manifest.DownloadCount - old; {
+		case increase < 0:
+			message = fmt.Sprintf("download count dropped from %d to %d", old, manifest.DownloadCount)
+		case factor > 0 && old > 0 && increase >= minDownloadJump && float64(increase) > factor*float64(old):
+			message = fmt.Sprintf("download count jumped from %d to %d", old, manifest.DownloadCount)
+		default:
+			continue
+		}
+
+		if mode == "hold" {
+			message += "; keeping the previous count"
+			manifest.DownloadCount = old
+			manifest.channelDownloads = nil
+		}
+
+		log.Printf("%s: %s", manifest.InternalName, message)
+		AnnotateManifest("warning", manifest.InternalName, message)
+	}
+
+	return nil
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var versionPattern = regexp.MustCompile(`^\d+(\.\d+){0,3}$`)
+
+// CompareVersions compares dotted numeric versions component-wise, treating missing components as zero.
+func CompareVersions(a, b string) int {
+	as, bs := strings.Split(NormalizeVersion(a), "."), strings.Split(NormalizeVersion(b), ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		x, _ := strconv.Atoi(as[i])
+		y, _ := strconv.Atoi(bs[i])
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	return len(as) - len(bs)
+}
+
+type Release struct {
+	Version      string `json:"Version"`
+	DownloadLink string `json:"DownloadLink"`
+	Size         int64  `json:"Size"`
+	LastUpdate   int64  `json:"LastUpdate"`
+}
+
+// ArchiveRelease keeps a copy of latest.zip as <version>.zip. Existing archives are never overwritten.
+func ArchiveRelease(directory, version string) error {
+	latest := filepath.Join(directory, "latest.zip")
+	if _, err := os.Stat(latest); os.IsNotExist(err) {
+		return nil
+	}
+
+	if !versionPattern.MatchString(version) {
+		return fmt.Errorf("invalid version to archive: %q", version)
+	}
+
+	path := filepath.Join(directory, version+".zip")
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+
+	return copyFile(latest, path)
+}
+
+func ListReleases(directory string) ([]string, error) {
+	entries, err := os.ReadDir(directory)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []string
+	for _, entry := range entries {
+		version, ok := strings.CutSuffix(entry.Name(), ".zip")
+		if !ok || entry.IsDir() || !versionPattern.MatchString(version) {
+			continue
+		}
+
+		versions = append(versions, version)
+	}
+
+	sort.Slice(versions, func(i, j int) bool {
+		return CompareVersions(versions[i], versions[j]) > 0
+	})
+
+	return versions, nil
+}
+
+func WriteReleases(domain, environment, name string) error {
+	directory := filepath.Join("plugins", environment, name)
+	versions, err := ListReleases(directory)
+	if err != nil {
+		return err
+	}
+
+	releases := []*Release{}
+	for _, version := range versions {
+		info, err := os.Stat(filepath.Join(directory, version+".zip"))
+		if err != nil {
+			return err
+		}
+
+		releases = append(releases, &Release{
+			Version:      version,
+			DownloadLink: fmt.Sprintf("https://%s/plugins/%s/%s/%s.zip", domain, environment, name, version),
+			Size:         info.Size(),
+			LastUpdate:   info.ModTime().Unix(),
+		})
+	}
+
+	content, err := json.MarshalIndent(releases, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(directory, "releases.json"), content, 0644)
+}
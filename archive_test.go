@@ -0,0 +1,47 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "1.0.0.0", b: "1.0.0.0", want: 0},
+		{a: "1.0", b: "1.0.0.0", want: 0},
+		{a: "1.2.3", b: "1.2.3.0", want: 0},
+		{a: "1.10.0.0", b: "1.9.0.0", want: 1},
+		{a: "1.9.0.0", b: "1.10.0.0", want: -1},
+		{a: "2", b: "1.99.99.99", want: 1},
+		{a: "1.0.0.1", b: "1.0.0.0", want: 1},
+		{a: "0.9", b: "1", want: -1},
+	}
+	for _, tt := range tests {
+		got := CompareVersions(tt.a, tt.b)
+		if (got > 0) != (tt.want > 0) || (got < 0) != (tt.want < 0) {
+			t.Errorf("CompareVersions(%q, %q) = %d, want the sign of %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestListReleases(t *testing.T) {
+	directory := t.TempDir()
+	for _, name := range []string{"1.9.0.0.zip", "1.10.0.0.zip", "1.2.zip", "latest.zip", "1.0.0.0.manifest", "beta.zip"} {
+		if err := os.WriteFile(filepath.Join(directory, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	versions, err := ListReleases(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"1.10.0.0", "1.9.0.0", "1.2"}; !slices.Equal(versions, want) {
+		t.Errorf("got %v, want %v", versions, want)
+	}
+}
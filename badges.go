@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// shieldsBadge is the response schema of the shields.io endpoint badge: https://shields.io/badges/endpoint-badge
+type shieldsBadge struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	Label         string `json:"label"`
+	Message       string `json:"message"`
+	Color         string `json:"color"`
+}
+
+// formatCount abbreviates a count in the metric style used by shields.io, e.g. 1234 as "1.2k".
+func formatCount(count int64) string {
+	if count < 1000 {
+		return fmt.Sprintf("%d", count)
+	}
+
+	value := float64(count)
+	for _, unit := range []string{"k", "M", "G"} {
+		value /= 1000
+		switch {
+		case value < 9.95:
+			return fmt.Sprintf("%.1f%s", value, unit)
+		case value < 999.5 || unit == "G":
+			return fmt.Sprintf("%.0f%s", value, unit)
+		}
+	}
+
+	return ""
+}
+
+// DumpBadges writes plugins/badges/<InternalName>/downloads.json and version.json for shields.io endpoint badges,
+// removing badges of plugins which are no longer in the master.
+func DumpBadges(manifests []*PluginManifest) error {
+	directory := filepath.Join("plugins", "badges")
+	if err := os.RemoveAll(directory); err != nil {
+		return err
+	}
+
+	for _, manifest := range manifests {
+		downloads := &shieldsBadge{
+			SchemaVersion: 1,
+			Label:         "downloads",
+			Message:       formatCount(manifest.DownloadCount),
+			Color:         "blue",
+		}
+		if err := writeJSON(filepath.Join(directory, manifest.InternalName, "downloads.json"), downloads); err != nil {
+			return err
+		}
+
+		version := &shieldsBadge{
+			SchemaVersion: 1,
+			Label:         "version",
+			Message:       "v" + latestVersion(manifest),
+			Color:         "blue",
+		}
+		if manifest.IsTestingExclusive || latestVersion(manifest) != manifest.AssemblyVersion {
+			version.Message += " (testing)"
+			version.Color = "orange"
+		}
+		if err := writeJSON(filepath.Join(directory, manifest.InternalName, "version.json"), version); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
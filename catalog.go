@@ -0,0 +1,202 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+const catalogStyle = `
+body { font-family: system-ui, sans-serif; max-width: 960px; margin: 0 auto; padding: 1rem; color: #222; }
+a { color: #2a6fdb; }
+header { border-bottom: 1px solid #ddd; margin-bottom: 1rem; }
+.plugins { list-style: none; padding: 0; display: grid; grid-template-columns: repeat(auto-fill, minmax(280px, 1fr)); gap: 1rem; }
+.plugins li { border: 1px solid #ddd; border-radius: 8px; padding: 1rem; }
+.icon { width: 64px; height: 64px; float: left; margin-right: 1rem; }
+.meta { color: #666; font-size: 0.9em; }
+.screenshots img { max-width: 100%; margin: 0.5rem 0; }
+pre { white-space: pre-wrap; background: #f6f6f6; padding: 1rem; border-radius: 8px; }
+code { background: #f6f6f6; padding: 0.1rem 0.3rem; }
+`
+
+var catalogTemplates = template.Must(template.New("catalog").Funcs(template.FuncMap{
+	"formatCount":   formatCount,
+	"latestVersion": latestVersion,
+	"date": func(timestamp int64) string {
+		return time.Unix(timestamp, 0).UTC().Format("2006-01-02")
+	},
+	"pathEscape": url.PathEscape,
+}).Parse(`
+{{- define "install" -}}
+<h2>Installation</h2>
+<ol>
+  <li>Open <code>/xlsettings</code> in game and select the <strong>Experimental</strong> tab.</li>
+  <li>Add <code>{{ .MasterURL }}</code> to <strong>Custom Plugin Repositories</strong> and save.</li>
+  <li>Search for the plugin in <code>/xlplugins</code> and install it.</li>
+</ol>
+{{- end -}}
+
+{{- define "downloads" -}}
+{{- if .Badges -}}
+<img src="https://img.shields.io/endpoint?url={{ .BadgeURL }}" alt="{{ formatCount .Manifest.DownloadCount }} downloads">
+{{- else -}}
+{{ formatCount .Manifest.DownloadCount }} downloads
+{{- end -}}
+{{- end -}}
+
+{{- define "index" -}}
+<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{ .Title }}</title>
+<style>{{ .Style }}</style>
+</head>
+<body>
+<header><h1>{{ .Title }}</h1></header>
+{{ template "install" . }}
+<h2>Plugins</h2>
+<ul class="plugins">
+{{- range .Plugins }}
+  <li>
+    {{- if .Manifest.IconURL }}<img class="icon" src="{{ .Manifest.IconURL }}" alt="">{{ end }}
+    <h3><a href="{{ pathEscape .Manifest.InternalName }}.html">{{ .Manifest.Name }}</a></h3>
+    <p>{{ .Manifest.Punchline }}</p>
+    <p class="meta">v{{ latestVersion .Manifest }} · {{ template "downloads" . }}</p>
+  </li>
+{{- end }}
+</ul>
+</body>
+</html>
+{{ end -}}
+
+{{- define "plugin" -}}
+<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{ .Manifest.Name }} - {{ .Title }}</title>
+<style>{{ .Style }}</style>
+</head>
+<body>
+<header><p><a href="index.html">{{ .Title }}</a></p></header>
+{{ if .Manifest.IconURL }}<img class="icon" src="{{ .Manifest.IconURL }}" alt="">{{ end }}
+<h1>{{ .Manifest.Name }}</h1>
+<p>{{ .Manifest.Punchline }}</p>
+<p class="meta">
+  v{{ .Manifest.AssemblyVersion }}{{ if .Manifest.TestingAssemblyVersion }} (testing: v{{ .Manifest.TestingAssemblyVersion }}){{ end }}
+  · API {{ .Manifest.DalamudApiLevel }}
+  {{- if .Manifest.Author }} · by {{ .Manifest.Author }}{{ end }}
+  {{- if .Manifest.LastUpdate }} · updated {{ date .Manifest.LastUpdate }}{{ end }}
+  · {{ template "downloads" . }}
+  {{- if .Manifest.RepoURL }} · <a href="{{ .Manifest.RepoURL }}">source</a>{{ end }}
+</p>
+{{- if .Manifest.Description }}
+<pre>{{ .Manifest.Description }}</pre>
+{{- end }}
+{{- if .Manifest.ImageURLs }}
+<div class="screenshots">
+{{- range .Manifest.ImageURLs }}
+  <img src="{{ . }}" alt="">
+{{- end }}
+</div>
+{{- end }}
+{{- if .Manifest.Changelog }}
+<h2>Changelog</h2>
+<pre>{{ .Manifest.Changelog }}</pre>
+{{- end }}
+{{- if .Manifest.TestingChangelog }}
+<h2>Testing changelog</h2>
+<pre>{{ .Manifest.TestingChangelog }}</pre>
+{{- end }}
+{{ template "install" . }}
+</body>
+</html>
+{{ end -}}
+`))
+
+type catalogPlugin struct {
+	Manifest *PluginManifest
+	Badges   bool
+	BadgeURL string
+}
+
+type catalogPage struct {
+	Title     string
+	Style     template.CSS
+	MasterURL string
+	Plugins   []*catalogPlugin
+
+	// Set on plugin pages only.
+	*catalogPlugin
+}
+
+func renderCatalogPage(path, name string, page *catalogPage) error {
+	var buf bytes.Buffer
+	if err := catalogTemplates.ExecuteTemplate(&buf, name, page); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// DumpCatalog renders a static HTML catalog of the listed plugins to plugins/catalog: an index page and a page for
+// each plugin, with a sitemap for search engines. Download counts are shown as the shields.io badges from DumpBadges when badges are emitted.
+func DumpCatalog(manifests []*PluginManifest, cfg *Config) error {
+	directory := filepath.Join("plugins", "catalog")
+	if err := os.RemoveAll(directory); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(directory, 0755); err != nil {
+		return err
+	}
+
+	title := cfg.RepositoryName
+	if title == "" {
+		title = cfg.HostingDomain
+	}
+
+	page := &catalogPage{
+		Title:     title,
+		Style:     template.CSS(strings.TrimSpace(catalogStyle)),
+		MasterURL: fmt.Sprintf("https://%s/plugins/master.json", cfg.HostingDomain),
+	}
+
+	listed := slices.DeleteFunc(slices.Clone(manifests), func(manifest *PluginManifest) bool {
+		return manifest.IsHide
+	})
+	slices.SortFunc(listed, func(a, b *PluginManifest) int {
+		return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	})
+
+	for _, manifest := range listed {
+		badgeURL := fmt.Sprintf("https://%s/plugins/badges/%s/downloads.json", cfg.HostingDomain, url.PathEscape(manifest.InternalName))
+		page.Plugins = append(page.Plugins, &catalogPlugin{
+			Manifest: manifest,
+			Badges:   cfg.EmitBadges,
+			BadgeURL: badgeURL,
+		})
+	}
+
+	if err := renderCatalogPage(filepath.Join(directory, "index.html"), "index", page); err != nil {
+		return err
+	}
+
+	for _, plugin := range page.Plugins {
+		p := *page
+		p.catalogPlugin = plugin
+		if err := renderCatalogPage(filepath.Join(directory, plugin.Manifest.InternalName+".html"), "plugin", &p); err != nil {
+			return err
+		}
+	}
+
+	return DumpSitemap(listed, cfg.HostingDomain)
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// zipChecksums computes the SHA256 of every latest.zip, keyed by "<channel>/<InternalName>".
+func zipChecksums() (map[string]string, error) {
+	paths, err := filepath.Glob(filepath.Join("plugins", "*", "*", "latest.zip"))
+	if err != nil {
+		return nil, err
+	}
+
+	checksums := map[string]string{}
+	for _, path := range paths {
+		sum, err := ComputeFileChecksum(path)
+		if err != nil {
+			return nil, err
+		}
+
+		checksums[filepath.ToSlash(filepath.Dir(strings.TrimPrefix(path, "plugins"+string(filepath.Separator))))] = sum
+	}
+
+	return checksums, nil
+}
+
+// counterFields are manifest fields updated by the download counter on nearly every run. They do not change the
+// published artifacts of a plugin, so they are ignored when detecting changes.
+var counterFields = []string{"DownloadCount"}
+
+// contentFieldNames lists the manifest fields compared when detecting changed plugins.
+func contentFieldNames() []string {
+	return slices.DeleteFunc(manifestFieldNames(), func(name string) bool {
+		return slices.Contains(counterFields, name)
+	})
+}
+
+// DetectChangedPlugins lists plugins whose manifest (apart from download counts) changed since the previous master or whose latest.zip changed
+// since the checksums recorded in plugins/checksums.json by the previous run.
+func DetectChangedPlugins(previous, current []*PluginManifest) ([]string, error) {
+	diff, err := DiffMasters(previous, current, contentFieldNames())
+	if err != nil {
+		return nil, err
+	}
+
+	changed := append(slices.Clone(diff.Added), diff.Removed...)
+	for _, change := range diff.Changes {
+		changed = append(changed, change.InternalName)
+	}
+
+	statePath := filepath.Join("plugins", "checksums.json")
+	recorded := map[string]string{}
+	if err = readOptionalJSON(statePath, &recorded); err != nil {
+		return nil, err
+	}
+
+	checksums, err := zipChecksums()
+	if err != nil {
+		return nil, err
+	}
+	for key, sum := range checksums {
+		if recorded[key] != sum {
+			_, name, _ := strings.Cut(key, "/")
+			changed = append(changed, name)
+		}
+	}
+	for key := range recorded {
+		if _, ok := checksums[key]; !ok {
+			_, name, _ := strings.Cut(key, "/")
+			changed = append(changed, name)
+		}
+	}
+
+	if err = writeJSON(statePath, checksums); err != nil {
+		return nil, err
+	}
+
+	sort.Strings(changed)
+	return slices.Compact(changed), nil
+}
+
+func changedPaths(names []string) []string {
+	var paths []string
+	for _, name := range names {
+		for _, environment := range []string{"stable", "testing"} {
+			path := filepath.Join("plugins", environment, name)
+			if _, err := os.Stat(path); err == nil {
+				paths = append(paths, filepath.ToSlash(path))
+			}
+		}
+	}
+
+	return paths
+}
+
+// WriteChangedPlugins writes the changed plugin names to path, one per line, and exposes them with their
+// directories as the changed-plugins and changed-paths outputs when running in GitHub Actions.
+func WriteChangedPlugins(names []string, path string) error {
+	content := strings.Join(names, "\n")
+	if len(names) > 0 {
+		content += "\n"
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return err
+	}
+
+	output := os.Getenv("GITHUB_OUTPUT")
+	if output == "" {
+		return nil
+	}
+
+	encoded, err := json.Marshal(append([]string{}, names...))
+	if err != nil {
+		return err
+	}
+
+	delimiter := make([]byte, 8)
+	if _, err = rand.Read(delimiter); err != nil {
+		return err
+	}
+	eof := "EOF_" + hex.EncodeToString(delimiter)
+
+	f, err := os.OpenFile(output, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = fmt.Fprintf(f, "changed-plugins=%s\nchanged-paths<<%s\n%s\n%s\n", encoded, eof, strings.Join(changedPaths(names), "\n"), eof)
+	return err
+}
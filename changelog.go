@@ -0,0 +1,370 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+	"unicode/utf8"
+)
+
+// conventionalCommitPattern matches subjects such as "feat(ui)!: add settings window".
+var conventionalCommitPattern = regexp.MustCompile(`^(\w+)(?:\([^)]*\))?!?:\s*(.+)$`)
+
+// droppedCommitTypes are conventional commit types which are not interesting to plugin users.
+var droppedCommitTypes = []string{"chore", "ci", "build", "test", "style"}
+
+// isIgnoredAuthor reports whether the commit author's name, email or GitHub login is listed in authors.
+func isIgnoredAuthor(commit *Commit, authors []string) bool {
+	return slices.ContainsFunc(authors, func(author string) bool {
+		return strings.EqualFold(author, commit.Commit.Author.Name) ||
+			strings.EqualFold(author, commit.Commit.Author.Email) ||
+			strings.EqualFold(author, commit.Author.Login)
+	})
+}
+
+// FilterCommits drops commits by CHANGELOG_IGNORED_AUTHORS and commits whose message matches one of
+// CHANGELOG_IGNORE_PATTERNS, as well as merge commits.
+func FilterCommits(commits []*Commit, cfg *Config) ([]*Commit, error) {
+	patterns := make([]*regexp.Regexp, 0, len(cfg.ChangelogIgnorePatterns))
+	for _, pattern := range cfg.ChangelogIgnorePatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid changelog ignore pattern %q: %w", pattern, err)
+		}
+
+		patterns = append(patterns, re)
+	}
+
+	return slices.DeleteFunc(commits, func(commit *Commit) bool {
+		if isIgnoredAuthor(commit, cfg.ChangelogIgnoredAuthors) || len(commit.Parents) > 1 {
+			return true
+		}
+
+		return slices.ContainsFunc(patterns, func(re *regexp.Regexp) bool {
+			return re.MatchString(commit.Commit.Message)
+		})
+	}), nil
+}
+
+// trailerPattern matches git trailer lines such as "Co-authored-by: name <email>" or "Signed-off-by: name <email>".
+var trailerPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9-]*: \S`)
+
+// stripTrailers removes the trailing paragraph of a commit message when every line in it is a git trailer.
+func stripTrailers(message string) string {
+	message = strings.TrimSpace(strings.ReplaceAll(message, "\r\n", "\n"))
+
+	index := strings.LastIndex(message, "\n\n")
+	if index < 0 {
+		return message
+	}
+
+	for _, line := range strings.Split(message[index+2:], "\n") {
+		if !trailerPattern.MatchString(line) {
+			return message
+		}
+	}
+
+	return strings.TrimSpace(message[:index])
+}
+
+// gitmojiPattern matches a leading gitmoji shortcode (":sparkles:") or emoji sequence at the start of a message. The
+// emoji blocks are listed explicitly since symbol classes such as \p{Sk} also contain ASCII characters like "`" and
+// "^", and Go's regexp does not support \p{Extended_Pictographic}.
+var gitmojiPattern = regexp.MustCompile(`^(?::[a-z0-9_+-]+:|[\x{2190}-\x{21FF}\x{2300}-\x{23FF}\x{2600}-\x{27BF}\x{2B00}-\x{2BFF}\x{1F000}-\x{1FAFF}\x{200D}\x{20E3}\x{FE0F}]+)\s*`)
+
+// gitmojiTypes maps common gitmojis to the conventional commit type they stand for.
+var gitmojiTypes = map[string]string{
+	":sparkles:":            "feat",
+	"✨":                     "feat",
+	":bug:":                 "fix",
+	"🐛":                     "fix",
+	":ambulance:":           "fix",
+	"🚑":                     "fix",
+	":zap:":                 "perf",
+	"⚡":                     "perf",
+	":recycle:":             "refactor",
+	"♻":                     "refactor",
+	":memo:":                "docs",
+	"📝":                     "docs",
+	":art:":                 "style",
+	"🎨":                     "style",
+	":white_check_mark:":    "test",
+	"✅":                     "test",
+	":construction_worker:": "ci",
+	"👷":                     "ci",
+	":green_heart:":         "ci",
+	"💚":                     "ci",
+	":arrow_up:":            "build",
+	"⬆":                     "build",
+	":wrench:":              "chore",
+	"🔧":                     "chore",
+	":bookmark:":            "chore",
+	"🔖":                     "chore",
+}
+
+// normalizeGitmoji strips the leading gitmoji of a commit message, or replaces it with the corresponding
+// conventional commit type when mode is "convert".
+func normalizeGitmoji(message, mode string) (string, error) {
+	match := gitmojiPattern.FindString(message)
+	switch mode {
+	case "keep":
+		return message, nil
+	case "strip":
+		return strings.TrimPrefix(message, match), nil
+	case "convert":
+		if match == "" {
+			return message, nil
+		}
+
+		rest := strings.TrimPrefix(message, match)
+		if kind, ok := gitmojiTypes[strings.ReplaceAll(strings.TrimSpace(match), "\uFE0F", "")]; ok && !conventionalCommitPattern.MatchString(strings.SplitN(rest, "\n", 2)[0]) {
+			return kind + ": " + rest, nil
+		}
+
+		return rest, nil
+	default:
+		return "", fmt.Errorf("unknown gitmoji mode: %s", mode)
+	}
+}
+
+// normalizeCommits returns copies of the commits with their messages cleaned up for display.
+func normalizeCommits(commits []*Commit, cfg *Config) ([]*Commit, error) {
+	normalized := make([]*Commit, 0, len(commits))
+	for _, commit := range commits {
+		c := *commit
+
+		var err error
+		if c.Commit.Message, err = normalizeGitmoji(c.Commit.Message, cfg.ChangelogGitmoji); err != nil {
+			return nil, err
+		}
+		if cfg.StripCommitTrailers {
+			c.Commit.Message = stripTrailers(c.Commit.Message)
+		}
+		if cfg.ChangelogSubjectOnly {
+			c.Commit.Message, _, _ = strings.Cut(strings.TrimSpace(c.Commit.Message), "\n")
+			c.Commit.Message = strings.TrimSpace(c.Commit.Message)
+		}
+
+		normalized = append(normalized, &c)
+	}
+
+	return normalized, nil
+}
+
+type changelogSection struct {
+	Title   string
+	Entries []string
+}
+
+type changelogFormatter struct {
+	markdown bool
+	repoURL  string
+}
+
+func newChangelogFormatter(format, repoURL string) (*changelogFormatter, error) {
+	switch format {
+	case "text":
+		return &changelogFormatter{}, nil
+	case "markdown":
+		return &changelogFormatter{markdown: true, repoURL: strings.TrimSuffix(repoURL, "/")}, nil
+	default:
+		return nil, fmt.Errorf("unknown changelog format: %s", format)
+	}
+}
+
+func (f *changelogFormatter) entry(commit *Commit, message string) string {
+	sha := commit.SHA[0:7]
+	if !f.markdown {
+		return fmt.Sprintf("%s: %s", sha, message)
+	}
+
+	if f.repoURL != "" {
+		return fmt.Sprintf("- [`%s`](%s/commit/%s) %s", sha, f.repoURL, commit.SHA, message)
+	}
+
+	return fmt.Sprintf("- `%s` %s", sha, message)
+}
+
+func (f *changelogFormatter) section(title string, entries []string) string {
+	if f.markdown {
+		return "### " + title + "\n" + strings.Join(entries, "\n")
+	}
+
+	return title + ":\n- " + strings.Join(entries, "\n- ")
+}
+
+// formatConventionalChangelog groups commits by their conventional commit type into Features, Fixes and Other
+// sections. Commits which do not follow the convention are listed under Other.
+func formatConventionalChangelog(commits []*Commit, formatter *changelogFormatter) string {
+	features := &changelogSection{Title: "Features"}
+	fixes := &changelogSection{Title: "Fixes"}
+	other := &changelogSection{Title: "Other"}
+
+	for _, commit := range commits {
+		message := commit.Commit.Message
+		section := other
+
+		subject, body, hasBody := strings.Cut(message, "\n")
+		if match := conventionalCommitPattern.FindStringSubmatch(subject); match != nil {
+			kind := strings.ToLower(match[1])
+			if slices.Contains(droppedCommitTypes, kind) {
+				continue
+			}
+
+			switch kind {
+			case "feat":
+				section = features
+			case "fix":
+				section = fixes
+			}
+			message = match[2]
+			if hasBody {
+				message += "\n" + body
+			}
+		}
+
+		section.Entries = append(section.Entries, formatter.entry(commit, message))
+	}
+
+	var blocks []string
+	for _, section := range []*changelogSection{features, fixes, other} {
+		if len(section.Entries) == 0 {
+			continue
+		}
+
+		blocks = append(blocks, formatter.section(section.Title, section.Entries))
+	}
+
+	return strings.Join(blocks, "\n\n")
+}
+
+// ChangelogTemplateData is passed to the template configured by CHANGELOG_TEMPLATE.
+type ChangelogTemplateData struct {
+	Version string
+	RepoURL string
+	Date    time.Time
+	Commits []*ChangelogTemplateCommit
+}
+
+type ChangelogTemplateCommit struct {
+	SHA      string
+	ShortSHA string
+	URL      string
+	Type     string
+	Subject  string
+	Body     string
+	Author   string
+}
+
+var changelogTemplateFuncs = template.FuncMap{
+	"truncate": func(length int, text string) string {
+		return truncateText(text, length)
+	},
+	"join":  strings.Join,
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	"trim":  strings.TrimSpace,
+}
+
+func executeChangelogTemplate(path string, commits []*Commit, version, repoURL string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	t, err := template.New(filepath.Base(path)).Funcs(changelogTemplateFuncs).Option("missingkey=error").Parse(string(content))
+	if err != nil {
+		return "", err
+	}
+
+	data := &ChangelogTemplateData{
+		Version: version,
+		RepoURL: repoURL,
+		Date:    time.Now().UTC(),
+	}
+	for _, commit := range commits {
+		subject, body, _ := strings.Cut(commit.Commit.Message, "\n")
+		c := &ChangelogTemplateCommit{
+			SHA:      commit.SHA,
+			ShortSHA: commit.SHA[0:7],
+			Subject:  subject,
+			Body:     strings.TrimSpace(body),
+			Author:   commit.Commit.Author.Name,
+		}
+		if repoURL != "" {
+			c.URL = fmt.Sprintf("%s/commit/%s", strings.TrimSuffix(repoURL, "/"), commit.SHA)
+		}
+		if match := conventionalCommitPattern.FindStringSubmatch(subject); match != nil {
+			c.Type = strings.ToLower(match[1])
+			c.Subject = match[2]
+		}
+
+		data.Commits = append(data.Commits, c)
+	}
+
+	var builder strings.Builder
+	if err = t.Execute(&builder, data); err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(builder.String()), nil
+}
+
+func renderChangelog(commits []*Commit, formatter *changelogFormatter, version, repoURL string, cfg *Config) (string, error) {
+	switch cfg.ChangelogStyle {
+	case "plain":
+		var lines []string
+		for _, commit := range commits {
+			lines = append(lines, formatter.entry(commit, commit.Commit.Message))
+		}
+
+		return strings.Join(lines, "\n"), nil
+	case "conventional":
+		return formatConventionalChangelog(commits, formatter), nil
+	case "template":
+		return executeChangelogTemplate(cfg.ChangelogTemplate, commits, version, repoURL)
+	default:
+		return "", fmt.Errorf("unknown changelog style: %s", cfg.ChangelogStyle)
+	}
+}
+
+// FormatChangelog renders commits as plain text or, when CHANGELOG_FORMAT is markdown, as a list linking each commit
+// to repoURL, or with the Go template at CHANGELOG_TEMPLATE when CHANGELOG_STYLE is template. Commits beyond
+// CHANGELOG_MAX_COMMITS or CHANGELOG_MAX_LENGTH are summarized in a trailer.
+func FormatChangelog(commits []*Commit, version, repoURL string, cfg *Config) (string, error) {
+	formatter, err := newChangelogFormatter(cfg.ChangelogFormat, repoURL)
+	if err != nil {
+		return "", err
+	}
+
+	if commits, err = normalizeCommits(commits, cfg); err != nil {
+		return "", err
+	}
+
+	count := len(commits)
+	if cfg.ChangelogMaxCommits > 0 {
+		count = min(count, cfg.ChangelogMaxCommits)
+	}
+
+	for ; count >= 0; count-- {
+		changelog, err := renderChangelog(commits[:count], formatter, version, repoURL, cfg)
+		if err != nil {
+			return "", err
+		}
+
+		if omitted := len(commits) - count; omitted > 0 {
+			changelog = strings.TrimSpace(fmt.Sprintf("%s\n\n…and %d more changes", changelog, omitted))
+		}
+
+		if cfg.ChangelogMaxLength <= 0 || utf8.RuneCountInString(changelog) <= cfg.ChangelogMaxLength || count == 0 {
+			return changelog, nil
+		}
+	}
+
+	return "", nil
+}
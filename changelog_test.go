@@ -0,0 +1,73 @@
+package main
+
+import "testing"
+
+func TestStripTrailers(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    string
+	}{
+		{
+			name:    "no body",
+			message: "fix: crash on load",
+			want:    "fix: crash on load",
+		},
+		{
+			name:    "trailers",
+			message: "fix: crash on load\n\nSigned-off-by: A <a@example.com>\nCo-authored-by: B <b@example.com>\n",
+			want:    "fix: crash on load",
+		},
+		{
+			name:    "body and trailers",
+			message: "fix: crash on load\r\n\r\nThe config was read too early.\r\n\r\nReviewed-by: A <a@example.com>",
+			want:    "fix: crash on load\n\nThe config was read too early.",
+		},
+		{
+			name:    "last paragraph is not only trailers",
+			message: "fix: crash on load\n\nNote: this also fixes the settings window\nwhich crashed as well.",
+			want:    "fix: crash on load\n\nNote: this also fixes the settings window\nwhich crashed as well.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stripTrailers(tt.message); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeGitmoji(t *testing.T) {
+	tests := []struct {
+		message string
+		strip   string
+		convert string
+	}{
+		{message: "✨ add a settings window", strip: "add a settings window", convert: "feat: add a settings window"},
+		{message: ":bug: fix a crash", strip: "fix a crash", convert: "fix: fix a crash"},
+		{message: "⬆️ bump Dalamud", strip: "bump Dalamud", convert: "build: bump Dalamud"},
+		{message: "♻️ feat: rework the UI", strip: "feat: rework the UI", convert: "feat: rework the UI"},
+		{message: "🧑‍💻 improve the dev setup", strip: "improve the dev setup", convert: "improve the dev setup"},
+		{message: "`README`: fix a typo", strip: "`README`: fix a typo", convert: "`README`: fix a typo"},
+		{message: "^1.2 bump", strip: "^1.2 bump", convert: "^1.2 bump"},
+		{message: "fix: nothing to do", strip: "fix: nothing to do", convert: "fix: nothing to do"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.message, func(t *testing.T) {
+			if got, err := normalizeGitmoji(tt.message, "keep"); err != nil || got != tt.message {
+				t.Errorf("keep: got %q, %v", got, err)
+			}
+			if got, err := normalizeGitmoji(tt.message, "strip"); err != nil || got != tt.strip {
+				t.Errorf("strip: got %q, %v, want %q", got, err, tt.strip)
+			}
+			if got, err := normalizeGitmoji(tt.message, "convert"); err != nil || got != tt.convert {
+				t.Errorf("convert: got %q, %v, want %q", got, err, tt.convert)
+			}
+		})
+	}
+
+	if _, err := normalizeGitmoji("✨ x", "remove"); err == nil {
+		t.Error("expected an error for an unknown mode")
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import "path/filepath"
+
+// clearTestingFields removes the testing build from a copy of a manifest put in a single-channel master.
+func clearTestingFields(m *PluginManifest) {
+	m.TestingAssemblyVersion = ""
+	m.IsTestingExclusive = false
+	m.DownloadLinkTesting = ""
+	m.TestingDalamudApiLevel = 0
+	m.TestingChangelog = ""
+	m.TestingSha256 = ""
+	m.TestingDeltaBaseVersion = ""
+	m.DownloadLinkTestingDelta = ""
+	m.DownloadLinkTestingSignature = ""
+}
+
+// stableChannelManifest returns the manifest of the stable build, or nil for testing exclusive plugins.
+func stableChannelManifest(manifest *PluginManifest) *PluginManifest {
+	if manifest.IsTestingExclusive {
+		return nil
+	}
+
+	m := *manifest
+	clearTestingFields(&m)
+	return &m
+}
+
+// testingChannelManifest returns the manifest with the testing build promoted to the main fields, or nil for plugins
+// without a testing build.
+func testingChannelManifest(manifest *PluginManifest) *PluginManifest {
+	if manifest.TestingAssemblyVersion == "" {
+		return nil
+	}
+
+	m := *manifest
+	m.AssemblyVersion = m.TestingAssemblyVersion
+	m.DalamudApiLevel = m.TestingDalamudApiLevel
+	m.DownloadLinkInstall = m.DownloadLinkTesting
+	m.DownloadLinkUpdate = m.DownloadLinkTesting
+	if m.TestingChangelog != "" {
+		m.Changelog = m.TestingChangelog
+	}
+	m.Sha256 = m.TestingSha256
+	m.DeltaBaseVersion = m.TestingDeltaBaseVersion
+	m.DownloadLinkDelta = m.DownloadLinkTestingDelta
+	m.DownloadLinkSignature = m.DownloadLinkTestingSignature
+	clearTestingFields(&m)
+	return &m
+}
+
+// DumpChannelMasters writes plugins/stable.json and plugins/testing.json, masters containing only the builds of one
+// channel for consumers that pin to it.
+func DumpChannelMasters(manifests []*PluginManifest) error {
+	for channel, convert := range map[string]func(*PluginManifest) *PluginManifest{
+		"stable":  stableChannelManifest,
+		"testing": testingChannelManifest,
+	} {
+		channelManifests := make([]*PluginManifest, 0, len(manifests))
+		for _, manifest := range manifests {
+			if m := convert(manifest); m != nil {
+				channelManifests = append(channelManifests, m)
+			}
+		}
+
+		if err := writeManifestsJSON(filepath.Join("plugins", channel+".json"), channelManifests, true); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+func ComputeFileChecksum(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err = io.Copy(hash, file); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// WriteFileChecksum computes the SHA256 of the file and writes it to <path>.sha256 in sha256sum format.
+func WriteFileChecksum(path string) (string, error) {
+	sum, err := ComputeFileChecksum(path)
+	if err != nil {
+		return "", err
+	}
+
+	content := fmt.Sprintf("%s  %s\n", sum, filepath.Base(path))
+	if err = os.WriteFile(path+".sha256", []byte(content), 0644); err != nil {
+		return "", err
+	}
+
+	return sum, nil
+}
+
+// WriteZipChecksum computes the SHA256 of latest.zip and writes it to latest.zip.sha256 in sha256sum format.
+func WriteZipChecksum(directory string) (string, error) {
+	path := filepath.Join(directory, "latest.zip")
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return "", nil
+	}
+
+	return WriteFileChecksum(path)
+}
+
+// WriteMasterChecksums writes master.json.sha256 and, when etag is set, master.json.etag holding a strong entity tag
+// web servers can send, so that clients and deploy scripts can cheaply detect whether the master changed.
+func WriteMasterChecksums(path string, etag bool) error {
+	sum, err := WriteFileChecksum(path)
+	if err != nil {
+		return err
+	}
+
+	if !etag {
+		if err = os.Remove(path + ".etag"); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		return nil
+	}
+
+	return os.WriteFile(path+".etag", []byte(fmt.Sprintf("%q\n", sum)), 0644)
+}
+
+// RemoveMasterChecksums removes the master.json.sha256 and master.json.etag of an earlier run, which would otherwise
+// go stale once checksums are disabled.
+func RemoveMasterChecksums(path string) error {
+	for _, extension := range []string{".sha256", ".etag"} {
+		if err := os.Remove(path + extension); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const cloudflareGraphQLEndpoint = "https://api.cloudflare.com/client/v4/graphql"
+
+// Cloudflare limits the time range of a single adaptive analytics query, so longer gaps are queried in windows.
+const cloudflareQueryWindow = 24 * time.Hour
+
+const cloudflareDownloadsQuery = `query ($zoneTag: string, $since: Time!, $until: Time!) {
+  viewer {
+    zones(filter: {zoneTag: $zoneTag}) {
+      httpRequestsAdaptiveGroups(
+        limit: 10000
+        filter: {datetime_geq: $since, datetime_lt: $until, clientRequestPath_like: "/plugins/%", requestSource: "eyeball", edgeResponseStatus: 200}
+      ) {
+        count
+        dimensions {
+          clientRequestPath
+        }
+      }
+    }
+  }
+}`
+
+// cloudflareState accumulates the counts queried so far, since Cloudflare only retains analytics for a limited time.
+type cloudflareState struct {
+	Until     int64            `json:"Until"`
+	Downloads map[string]int64 `json:"Downloads"`
+}
+
+func queryCloudflareDownloads(cfg *Config, since, until time.Time) (map[string]int64, error) {
+	payload, err := json.Marshal(map[string]any{
+		"query": cloudflareDownloadsQuery,
+		"variables": map[string]string{
+			"zoneTag": cfg.CloudflareZoneID,
+			"since":   since.UTC().Format(time.RFC3339),
+			"until":   until.UTC().Format(time.RFC3339),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := http.NewRequest(http.MethodPost, cloudflareGraphQLEndpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+
+	request.Header.Set("User-Agent", userAgent)
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Authorization", "Bearer "+cfg.CloudflareAPIToken)
+
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return nil, &retryableError{err}
+	}
+
+	defer response.Body.Close()
+	if response.StatusCode == http.StatusTooManyRequests || response.StatusCode >= 500 {
+		return nil, &retryableError{fmt.Errorf("unexpected Cloudflare status: %s", response.Status)}
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected Cloudflare status: %s", response.Status)
+	}
+
+	var result struct {
+		Data struct {
+			Viewer struct {
+				Zones []struct {
+					Groups []struct {
+						Count      int64 `json:"count"`
+						Dimensions struct {
+							ClientRequestPath string `json:"clientRequestPath"`
+						} `json:"dimensions"`
+					} `json:"httpRequestsAdaptiveGroups"`
+				} `json:"zones"`
+			} `json:"viewer"`
+		} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err = json.NewDecoder(response.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	if len(result.Errors) > 0 {
+		var messages []string
+		for _, e := range result.Errors {
+			messages = append(messages, e.Message)
+		}
+
+		return nil, errors.New(strings.Join(messages, "; "))
+	}
+
+	downloads := map[string]int64{}
+	for _, zone := range result.Data.Viewer.Zones {
+		for _, group := range zone.Groups {
+			path := group.Dimensions.ClientRequestPath
+			if !strings.HasSuffix(path, "/download") && !strings.HasSuffix(path, "/latest.zip") {
+				continue
+			}
+
+			channel, name := splitDownloadKey(path)
+			if channel != "stable" && channel != "testing" {
+				continue
+			}
+
+			downloads[channel+"/"+name] += group.Count
+		}
+	}
+
+	return downloads, nil
+}
+
+// CloudflareStatsProvider counts downloads with the Cloudflare GraphQL Analytics API.
+type CloudflareStatsProvider struct {
+	cfg *Config
+}
+
+func (p *CloudflareStatsProvider) FetchDownloadStatistics(map[string]string) (map[string]int64, error) {
+	return FetchCloudflareStatistics(p.cfg, time.Now())
+}
+
+// FetchCloudflareStatistics counts requests to plugin download paths with the Cloudflare GraphQL Analytics API. The
+// counts since the previous run are added to the totals kept in plugins/stats/cloudflare.json.
+func FetchCloudflareStatistics(cfg *Config, now time.Time) (map[string]int64, error) {
+	if cfg.CloudflareAPIToken == "" || cfg.CloudflareZoneID == "" {
+		return nil, errors.New("CLOUDFLARE_API_TOKEN and CLOUDFLARE_ZONE_ID are required for the cloudflare stats provider")
+	}
+
+	path := filepath.Join("plugins", "stats", "cloudflare.json")
+	state := &cloudflareState{}
+	if err := readOptionalJSON(path, state); err != nil {
+		return nil, err
+	}
+	if state.Downloads == nil {
+		state.Downloads = map[string]int64{}
+	}
+
+	since := time.Unix(state.Until, 0)
+	if state.Until == 0 {
+		since = now.Add(-cloudflareQueryWindow)
+		log.Printf("no Cloudflare statistics state, counting downloads since %s", since.UTC().Format(time.RFC3339))
+	}
+
+	for since.Before(now) {
+		until := since.Add(cloudflareQueryWindow)
+		if until.After(now) {
+			until = now
+		}
+
+		downloads, err := withRetry(cfg.StatsRetries, cfg.StatsRetryDelay, func() (map[string]int64, error) {
+			return queryCloudflareDownloads(cfg, since, until)
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for key, count := range downloads {
+			state.Downloads[key] += count
+		}
+		since = until
+	}
+
+	state.Until = now.Unix()
+	if err := writeJSON(path, state); err != nil {
+		return nil, err
+	}
+
+	downloads := make(map[string]int64, len(state.Downloads))
+	for key, count := range state.Downloads {
+		downloads[key] = count
+	}
+
+	return downloads, nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+)
+
+// precompressedExtensions are the variants static hosts such as nginx (gzip_static, brotli_static) or Caddy
+// (precompressed) serve in place of the original file.
+var precompressedExtensions = []string{".gz", ".br"}
+
+func writeGzip(path string, content []byte) error {
+	var buf bytes.Buffer
+	writer, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if err != nil {
+		return err
+	}
+	if _, err = writer.Write(content); err != nil {
+		return err
+	}
+	if err = writer.Close(); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// PrecompressFile writes gzip and brotli variants next to the file. Since the standard library has no brotli encoder,
+// the brotli variant is written with the brotli command and skipped when it is not installed.
+func PrecompressFile(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	if err = writeGzip(path+".gz", content); err != nil {
+		return err
+	}
+
+	if _, err = exec.LookPath("brotli"); err != nil {
+		log.Printf("brotli is not installed; skipping %s.br", path)
+		return removePrecompressed(path, ".br")
+	}
+
+	output, err := exec.Command("brotli", "--force", "--best", "--output="+path+".br", path).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("brotli failed: %w: %s", err, bytes.TrimSpace(output))
+	}
+
+	return nil
+}
+
+// removePrecompressed removes variants left by a previous run, which would otherwise be served instead of the
+// updated file.
+func removePrecompressed(path string, extensions ...string) error {
+	for _, extension := range extensions {
+		if err := os.Remove(path + extension); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"syscall"
+	"time"
+)
+
+var counterPathPattern = regexp.MustCompile(`^/plugins/(stable|testing)/([A-Za-z0-9_.-]+)/download$`)
+
+// DownloadCounter counts downloads per "<channel>/<InternalName>" key, the format LoadDownloadStatistics resolves.
+type DownloadCounter struct {
+	path   string
+	root   string
+	master string
+	base   string
+
+	mu     sync.Mutex
+	counts map[string]int64
+	names  map[string]bool
+	dirty  bool
+}
+
+// NewDownloadCounter creates a counter persisting to path. Downloads are only counted for plugins whose latest.zip
+// exists under root, or which are listed in the master at master, so that clients cannot fill the counts with
+// made-up names.
+func NewDownloadCounter(path, root, master, base string) (*DownloadCounter, error) {
+	counter := &DownloadCounter{path: path, root: root, master: master, base: base, counts: map[string]int64{}}
+	if err := readOptionalJSON(path, &counter.counts); err != nil {
+		return nil, err
+	}
+	if err := counter.ReloadMaster(); err != nil {
+		return nil, err
+	}
+
+	return counter, nil
+}
+
+// ReloadMaster reads the InternalNames of the master again so that newly published plugins are counted.
+func (c *DownloadCounter) ReloadMaster() error {
+	if c.master == "" {
+		return nil
+	}
+
+	manifests, err := ReadMaster(c.master)
+	if err != nil {
+		return err
+	}
+
+	names := map[string]bool{}
+	for _, manifest := range manifests {
+		names[manifest.InternalName] = true
+	}
+
+	c.mu.Lock()
+	c.names = names
+	c.mu.Unlock()
+	return nil
+}
+
+// isKnown reports whether downloads of the plugin are counted.
+func (c *DownloadCounter) isKnown(channel, name string) bool {
+	if c.root != "" {
+		_, err := os.Stat(filepath.Join(c.root, "plugins", channel, name, "latest.zip"))
+		return err == nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.names[name]
+}
+
+// Flush persists the counts when they have changed since the last flush.
+func (c *DownloadCounter) Flush() error {
+	c.mu.Lock()
+	if !c.dirty {
+		c.mu.Unlock()
+		return nil
+	}
+	content, err := json.MarshalIndent(c.counts, "", "  ")
+	c.dirty = false
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so that a crash never leaves truncated counts behind.
+	temporary := c.path + ".tmp"
+	if err = os.WriteFile(temporary, content, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(temporary, c.path)
+}
+
+func (c *DownloadCounter) serveDownload(w http.ResponseWriter, r *http.Request) {
+	match := counterPathPattern.FindStringSubmatch(r.URL.Path)
+	if match == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	channel, name := match[1], match[2]
+	if !c.isKnown(channel, name) {
+		http.NotFound(w, r)
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		c.mu.Lock()
+		c.counts[channel+"/"+name]++
+		c.dirty = true
+		c.mu.Unlock()
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("%s/plugins/%s/%s/latest.zip", c.base, channel, name), http.StatusFound)
+}
+
+func (c *DownloadCounter) serveStatistics(w http.ResponseWriter, _ *http.Request) {
+	c.mu.Lock()
+	content, err := json.Marshal(c.counts)
+	c.mu.Unlock()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache")
+	_, _ = w.Write(content)
+}
+
+// Handler serves the counting redirects at /plugins/<channel>/<InternalName>/download and the counts at
+// /plugins/downloads. With a root directory, the hosting tree is served as well.
+func (c *DownloadCounter) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /plugins/downloads", c.serveStatistics)
+	mux.HandleFunc("GET /plugins/{channel}/{name}/download", c.serveDownload)
+	if c.root != "" {
+		mux.Handle("GET /", http.FileServer(http.Dir(c.root)))
+	}
+
+	return mux
+}
+
+// serveCommand runs the download counter until it receives SIGINT or SIGTERM, flushing the counts periodically and
+// on shutdown.
+func serveCommand(args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	listen := flags.String("listen", ":8080", "address to listen on")
+	counts := flags.String("counts", filepath.Join("plugins", "stats", "counts.json"), "path of the file to persist the counts to")
+	root := flags.String("root", "", "directory of the hosting tree to serve and to check download targets against")
+	master := flags.String("master", "", "master to check download targets against when no root directory is served")
+	base := flags.String("redirect-base", "", "URL prefix of the redirects to latest.zip, e.g. https://example.com")
+	interval := flags.Duration("flush-interval", time.Minute, "interval to persist the counts at")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return errors.New("usage: serve [-listen <address>] [-counts <path>] (-root <directory> | -master <path>) [-redirect-base <URL>] [-flush-interval <duration>]")
+	}
+
+	if *root == "" && *master == "" {
+		return errors.New("either -root or -master is required to check download targets")
+	}
+
+	counter, err := NewDownloadCounter(*counts, *root, *master, *base)
+	if err != nil {
+		return fmt.Errorf("failed to load counts: %w", err)
+	}
+
+	server := &http.Server{
+		Addr:              *listen,
+		Handler:           counter.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(*interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := counter.Flush(); err != nil {
+					log.Printf("failed to persist counts: %v", err)
+				}
+				if err := counter.ReloadMaster(); err != nil {
+					log.Printf("failed to reload the master: %v", err)
+				}
+			case <-ctx.Done():
+				shutdown, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+				defer cancel()
+
+				if err := server.Shutdown(shutdown); err != nil {
+					log.Printf("failed to shut down: %v", err)
+				}
+				return
+			}
+		}
+	}()
+
+	log.Printf("serving download counter on %s", *listen)
+	if err = server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	// Wait for in-flight requests so that their counts are persisted too.
+	<-done
+	return counter.Flush()
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Delta files are zstd frames compressed with the previous zip as a raw dictionary, the format of
+// `zstd --patch-from`. Clients apply them with `zstd -d --patch-from=<base.zip> <delta> -o latest.zip` or with
+// `generator apply-delta`.
+const (
+	deltaExtension = ".zst"
+	// deltaMaxSize bounds the size of a delta result, so that a crafted delta cannot exhaust memory.
+	deltaMaxSize = 512 << 20
+)
+
+// deltaWindowSize returns the smallest window which lets the new file reference the whole base file.
+func deltaWindowSize(old, new []byte) (int, error) {
+	size := zstd.MinWindowSize
+	for size < len(old)+len(new) {
+		size <<= 1
+	}
+	if size > zstd.MaxWindowSize {
+		return 0, fmt.Errorf("files are too large for a delta: %d + %d bytes", len(old), len(new))
+	}
+
+	return size, nil
+}
+
+func CreateDelta(old, new []byte, w io.Writer) error {
+	window, err := deltaWindowSize(old, new)
+	if err != nil {
+		return err
+	}
+
+	// The best compression level is the only one that searches the whole dictionary for matches.
+	encoder, err := zstd.NewWriter(w,
+		zstd.WithEncoderDictRaw(0, old),
+		zstd.WithWindowSize(window),
+		zstd.WithEncoderLevel(zstd.SpeedBestCompression),
+		zstd.WithEncoderCRC(true),
+	)
+	if err != nil {
+		return err
+	}
+
+	if _, err = encoder.Write(new); err != nil {
+		encoder.Close()
+		return err
+	}
+
+	return encoder.Close()
+}
+
+func ApplyDelta(old []byte, r io.Reader) ([]byte, error) {
+	decoder, err := zstd.NewReader(r,
+		zstd.WithDecoderDictRaw(0, old),
+		zstd.WithDecoderMaxWindow(uint64(len(old))+deltaMaxSize),
+		zstd.WithDecoderMaxMemory(deltaMaxSize),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer decoder.Close()
+
+	// Read one byte past the limit to tell a result of exactly the limit from a larger one.
+	result, err := io.ReadAll(io.LimitReader(decoder, deltaMaxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(result) > deltaMaxSize {
+		return nil, fmt.Errorf("delta result exceeds %d bytes", deltaMaxSize)
+	}
+
+	return result, nil
+}
+
+type DeltaArtifact struct {
+	BaseVersion string
+	Filename    string
+}
+
+// UpdateDelta keeps the previously published zip under delta/base.zip and, when the version changes, writes
+// delta/<base>_<version>.zst between the two. The delta to the current version from the newest base version is
+// returned and every other file in delta/ except the base is removed.
+func UpdateDelta(directory, version string) (*DeltaArtifact, error) {
+	latest := filepath.Join(directory, "latest.zip")
+	if _, err := os.Stat(latest); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	deltaDir := filepath.Join(directory, "delta")
+	if err := os.MkdirAll(deltaDir, 0755); err != nil {
+		return nil, err
+	}
+
+	basePath := filepath.Join(deltaDir, "base.zip")
+	baseVersionPath := filepath.Join(deltaDir, "base.version")
+
+	baseVersion, err := os.ReadFile(baseVersionPath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	if len(baseVersion) > 0 && string(baseVersion) != version {
+		old, err := os.ReadFile(basePath)
+		if err != nil {
+			return nil, err
+		}
+
+		current, err := os.ReadFile(latest)
+		if err != nil {
+			return nil, err
+		}
+
+		var buffer bytes.Buffer
+		if err = CreateDelta(old, current, &buffer); err != nil {
+			return nil, err
+		}
+
+		filename := fmt.Sprintf("%s_%s%s", baseVersion, version, deltaExtension)
+		if err = os.WriteFile(filepath.Join(deltaDir, filename), buffer.Bytes(), 0644); err != nil {
+			return nil, err
+		}
+	}
+
+	if string(baseVersion) != version {
+		if err = copyFile(latest, basePath); err != nil {
+			return nil, err
+		}
+		if err = os.WriteFile(baseVersionPath, []byte(version), 0644); err != nil {
+			return nil, err
+		}
+	}
+
+	entries, err := os.ReadDir(deltaDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var artifact *DeltaArtifact
+	suffix := "_" + version + deltaExtension
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasSuffix(name, suffix) {
+			continue
+		}
+
+		base := strings.TrimSuffix(name, suffix)
+		if artifact == nil || CompareVersions(base, artifact.BaseVersion) > 0 {
+			artifact = &DeltaArtifact{BaseVersion: base, Filename: name}
+		}
+	}
+
+	// The master only links the newest delta, so every other one (including those of former versions) is stale.
+	for _, entry := range entries {
+		name := entry.Name()
+		if name == "base.zip" || name == "base.version" || (artifact != nil && name == artifact.Filename) {
+			continue
+		}
+
+		if err = os.RemoveAll(filepath.Join(deltaDir, name)); err != nil {
+			return nil, err
+		}
+	}
+
+	return artifact, nil
+}
+
+func applyDeltaCommand(args []string) error {
+	if len(args) != 3 {
+		return errors.New("usage: apply-delta <base.zip> <patch.zst> <output.zip>")
+	}
+
+	old, err := os.ReadFile(args[0])
+	if err != nil {
+		return err
+	}
+
+	patch, err := os.Open(args[1])
+	if err != nil {
+		return err
+	}
+	defer patch.Close()
+
+	result, err := ApplyDelta(old, patch)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(args[2], result, 0644)
+}
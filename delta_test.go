@@ -0,0 +1,195 @@
+package main
+
+import (
+	"bytes"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestDeltaRoundTrip(t *testing.T) {
+	random := rand.New(rand.NewPCG(1, 2))
+	randomBytes := func(n int) []byte {
+		b := make([]byte, n)
+		for i := range b {
+			b[i] = byte(random.UintN(256))
+		}
+		return b
+	}
+
+	base := randomBytes(64 * 1024)
+	edited := append(bytes.Clone(base[:20000]), randomBytes(100)...)
+	edited = append(edited, base[20037:]...)
+
+	tests := []struct {
+		name string
+		old  []byte
+		new  []byte
+	}{
+		{name: "identical", old: base, new: base},
+		{name: "edited", old: base, new: edited},
+		{name: "appended", old: base, new: append(bytes.Clone(base), randomBytes(1000)...)},
+		{name: "truncated", old: base, new: base[:1000]},
+		{name: "unrelated", old: base, new: randomBytes(5000)},
+		{name: "empty base", old: nil, new: base[:100]},
+		{name: "empty result", old: base, new: []byte{}},
+		{name: "shorter than a block", old: []byte("abc"), new: []byte("abcd")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var delta bytes.Buffer
+			if err := CreateDelta(tt.old, tt.new, &delta); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := ApplyDelta(tt.old, &delta)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, tt.new) {
+				t.Errorf("ApplyDelta returned %d bytes which differ from the expected %d bytes", len(got), len(tt.new))
+			}
+		})
+	}
+}
+
+func TestDeltaIsSmallForSmallEdits(t *testing.T) {
+	random := rand.New(rand.NewPCG(3, 4))
+	base := make([]byte, 256*1024)
+	for i := range base {
+		base[i] = byte(random.UintN(256))
+	}
+	edited := bytes.Clone(base)
+	copy(edited[100000:], "patched")
+
+	var delta bytes.Buffer
+	if err := CreateDelta(base, edited, &delta); err != nil {
+		t.Fatal(err)
+	}
+	if delta.Len() > len(edited)/10 {
+		t.Errorf("delta of a 7 byte edit is %d bytes", delta.Len())
+	}
+}
+
+func TestApplyDeltaRejectsWrongBase(t *testing.T) {
+	random := rand.New(rand.NewPCG(5, 6))
+	base := make([]byte, 4096)
+	for i := range base {
+		base[i] = byte(random.UintN(256))
+	}
+	other := bytes.Clone(base)
+	other[100] ^= 0xff
+
+	// the frame checksum covers the result, so a base which changes any referenced byte is detected
+	var delta bytes.Buffer
+	if err := CreateDelta(base, append(bytes.Clone(base), "appended"...), &delta); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ApplyDelta(other, &delta); err == nil {
+		t.Error("expected an error for a different base file")
+	}
+}
+
+func TestApplyDeltaRejectsCorruptDelta(t *testing.T) {
+	base := bytes.Repeat([]byte("a"), 1024)
+	var delta bytes.Buffer
+	if err := CreateDelta(base, bytes.Repeat([]byte("a"), 2048), &delta); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		delta []byte
+	}{
+		{name: "garbage", delta: []byte("DIVDELTA not a zstd frame")},
+		{name: "truncated", delta: delta.Bytes()[:delta.Len()/2]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ApplyDelta(base, bytes.NewReader(tt.delta)); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestUpdateDeltaReturnsNewestBase(t *testing.T) {
+	directory := t.TempDir()
+	files := map[string]string{
+		"latest.zip":                  "zip",
+		"delta/base.zip":              "zip",
+		"delta/base.version":          "2.0.0.0",
+		"delta/1.10.0.0_2.0.0.0.zst":  "",
+		"delta/1.9.0.0_2.0.0.0.zst":   "",
+		"delta/1.0.0.0_2.0.0.0.zst":   "",
+		"delta/0.9.0.0_1.0.0.0.zst":   "",
+		"delta/0.9.0.0_1.0.0.0.delta": "",
+	}
+	for name, content := range files {
+		path := filepath.Join(directory, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	artifact, err := UpdateDelta(directory, "2.0.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if artifact == nil || artifact.BaseVersion != "1.10.0.0" {
+		t.Fatalf("UpdateDelta = %+v, want the delta from 1.10.0.0", artifact)
+	}
+
+	entries, err := os.ReadDir(filepath.Join(directory, "delta"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	if want := []string{artifact.Filename, "base.version", "base.zip"}; !slices.Equal(names, want) {
+		t.Errorf("delta/ contains %v, want %v", names, want)
+	}
+}
+
+func TestUpdateDeltaCreatesDelta(t *testing.T) {
+	directory := t.TempDir()
+	if err := os.WriteFile(filepath.Join(directory, "latest.zip"), []byte("version one"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if artifact, err := UpdateDelta(directory, "1.0.0.0"); err != nil || artifact != nil {
+		t.Fatalf("UpdateDelta = %+v, %v, want no delta for the first version", artifact, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(directory, "latest.zip"), []byte("version two"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	artifact, err := UpdateDelta(directory, "2.0.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if artifact == nil || artifact.Filename != "1.0.0.0_2.0.0.0.zst" {
+		t.Fatalf("UpdateDelta = %+v, want 1.0.0.0_2.0.0.0.zst", artifact)
+	}
+
+	delta, err := os.Open(filepath.Join(directory, "delta", artifact.Filename))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer delta.Close()
+
+	got, err := ApplyDelta([]byte("version one"), delta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "version two" {
+		t.Errorf("ApplyDelta = %q, want %q", got, "version two")
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// RepositoryDescriptor describes the repository for users adding it to the custom plugin repositories of Dalamud.
+type RepositoryDescriptor struct {
+	Name        string `json:"Name"`
+	Description string `json:"Description,omitempty"`
+	URL         string `json:"Url"`
+	IconURL     string `json:"IconUrl,omitempty"`
+	PluginCount int    `json:"PluginCount"`
+}
+
+// DumpRepositoryDescriptor writes plugins/repo.json, pointing to the master on the hosting domain.
+func DumpRepositoryDescriptor(manifests []*PluginManifest, cfg *Config) error {
+	name := cfg.RepositoryName
+	if name == "" {
+		name = cfg.HostingDomain
+	}
+
+	var count int
+	for _, manifest := range manifests {
+		if !manifest.IsHide {
+			count++
+		}
+	}
+
+	return writeJSON(filepath.Join("plugins", "repo.json"), &RepositoryDescriptor{
+		Name:        name,
+		Description: cfg.RepositoryDescription,
+		URL:         fmt.Sprintf("https://%s/plugins/master.json", cfg.HostingDomain),
+		IconURL:     cfg.RepositoryIconURL,
+		PluginCount: count,
+	})
+}
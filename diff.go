@@ -0,0 +1,204 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// diffFields are the manifest fields reported by the diff command.
+var diffFields = []string{
+	"AssemblyVersion",
+	"TestingAssemblyVersion",
+	"DalamudApiLevel",
+	"TestingDalamudApiLevel",
+	"DownloadLinkInstall",
+	"DownloadLinkUpdate",
+	"DownloadLinkTesting",
+	"IsHide",
+}
+
+type ManifestChange struct {
+	InternalName string `json:"InternalName"`
+	Field        string `json:"Field"`
+	Old          any    `json:"Old"`
+	New          any    `json:"New"`
+}
+
+type MasterDiff struct {
+	Added   []string          `json:"Added"`
+	Removed []string          `json:"Removed"`
+	Changes []*ManifestChange `json:"Changes"`
+}
+
+func (d *MasterDiff) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changes) == 0
+}
+
+// manifestFieldNames lists the json names of all manifest fields.
+func manifestFieldNames() []string {
+	var names []string
+	t := reflect.TypeOf(PluginManifest{})
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// DiffMasters compares two masters by InternalName. Only the given fields are compared, or every field when fields
+// is empty.
+func DiffMasters(old, current []*PluginManifest, fields []string) (*MasterDiff, error) {
+	if len(fields) == 0 {
+		fields = manifestFieldNames()
+	}
+
+	oldMap := map[string]*PluginManifest{}
+	for _, manifest := range old {
+		oldMap[manifest.InternalName] = manifest
+	}
+
+	diff := &MasterDiff{Added: []string{}, Removed: []string{}, Changes: []*ManifestChange{}}
+	newMap := map[string]*PluginManifest{}
+	for _, manifest := range current {
+		newMap[manifest.InternalName] = manifest
+
+		previous, ok := oldMap[manifest.InternalName]
+		if !ok {
+			diff.Added = append(diff.Added, manifest.InternalName)
+			continue
+		}
+
+		for _, name := range fields {
+			field, ok := lookupManifestField(name)
+			if !ok {
+				return nil, fmt.Errorf("unknown manifest field: %s", name)
+			}
+
+			a := reflect.ValueOf(previous).Elem().FieldByIndex(field.Index).Interface()
+			b := reflect.ValueOf(manifest).Elem().FieldByIndex(field.Index).Interface()
+			if reflect.DeepEqual(a, b) {
+				continue
+			}
+
+			diff.Changes = append(diff.Changes, &ManifestChange{
+				InternalName: manifest.InternalName,
+				Field:        name,
+				Old:          a,
+				New:          b,
+			})
+		}
+	}
+
+	for _, manifest := range old {
+		if _, ok := newMap[manifest.InternalName]; !ok {
+			diff.Removed = append(diff.Removed, manifest.InternalName)
+		}
+	}
+
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.SliceStable(diff.Changes, func(i, j int) bool {
+		return diff.Changes[i].InternalName < diff.Changes[j].InternalName
+	})
+
+	return diff, nil
+}
+
+// LoadMaster reads a master from a local path or downloads it from an http(s) URL.
+func LoadMaster(source string) ([]*PluginManifest, error) {
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		return ReadMaster(source)
+	}
+
+	request, err := http.NewRequest(http.MethodGet, source, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	request.Header.Set("User-Agent", userAgent)
+
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status: %s", response.Status)
+	}
+
+	var manifests []*PluginManifest
+	if err = json.NewDecoder(response.Body).Decode(&manifests); err != nil {
+		return nil, err
+	}
+
+	return manifests, nil
+}
+
+func writeDiffText(w io.Writer, diff *MasterDiff) error {
+	var lines []string
+	for _, name := range diff.Added {
+		lines = append(lines, "+ "+name)
+	}
+	for _, name := range diff.Removed {
+		lines = append(lines, "- "+name)
+	}
+	for _, change := range diff.Changes {
+		lines = append(lines, fmt.Sprintf("~ %s.%s: %v -> %v", change.InternalName, change.Field, change.Old, change.New))
+	}
+	if len(lines) == 0 {
+		lines = append(lines, "no changes")
+	}
+
+	_, err := fmt.Fprintln(w, strings.Join(lines, "\n"))
+	return err
+}
+
+func diffCommand(cfg *Config, args []string, w io.Writer) error {
+	flags := flag.NewFlagSet("diff", flag.ContinueOnError)
+	against := flags.String("against", fmt.Sprintf("https://%s/plugins/master.json", cfg.HostingDomain), "URL or path of the master to compare against")
+	current := flags.String("current", filepath.Join("plugins", "master.json"), "URL or path of the master to compare")
+	format := flags.String("format", "text", "output format: text or json")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return errors.New("usage: diff [-against <URL|path>] [-current <URL|path>] [-format text|json]")
+	}
+
+	old, err := LoadMaster(*against)
+	if err != nil {
+		return fmt.Errorf("failed to load %s: %w", *against, err)
+	}
+	next, err := LoadMaster(*current)
+	if err != nil {
+		return fmt.Errorf("failed to load %s: %w", *current, err)
+	}
+
+	diff, err := DiffMasters(old, next, diffFields)
+	if err != nil {
+		return err
+	}
+
+	switch *format {
+	case "text":
+		return writeDiffText(w, diff)
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(diff)
+	default:
+		return fmt.Errorf("unknown diff format: %s", *format)
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// tomlString quotes a TOML basic string.
+func tomlString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&b, `\u%04X`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+
+	return b.String()
+}
+
+// DIP17Manifest is the manifest.toml read by the plogon build pipeline of goatcorp/DalamudPluginsD17.
+type DIP17Manifest struct {
+	Repository  string
+	Commit      string
+	Owners      []string
+	ProjectPath string
+	Changelog   string
+}
+
+func (m *DIP17Manifest) String() string {
+	owners := make([]string, len(m.Owners))
+	for i, owner := range m.Owners {
+		owners[i] = tomlString(owner)
+	}
+
+	var b strings.Builder
+	b.WriteString("[plugin]\n")
+	fmt.Fprintf(&b, "repository = %s\n", tomlString(m.Repository))
+	fmt.Fprintf(&b, "commit = %s\n", tomlString(m.Commit))
+	fmt.Fprintf(&b, "owners = [%s]\n", strings.Join(owners, ", "))
+	if m.ProjectPath != "" {
+		fmt.Fprintf(&b, "project_path = %s\n", tomlString(m.ProjectPath))
+	}
+	if m.Changelog != "" {
+		fmt.Fprintf(&b, "changelog = %s\n", tomlString(m.Changelog))
+	}
+
+	return b.String()
+}
+
+// buildDIP17Manifest describes the build of a channel, or returns nil when the source repository is unknown. The
+// commit is taken from event.json of the plugin directory, and left empty to be filled in by hand when it is not known.
+func buildDIP17Manifest(manifest *PluginManifest, environment, changelog string, owners []string, projectPath string) (*DIP17Manifest, error) {
+	directory := filepath.Join("plugins", environment, manifest.InternalName)
+	event, err := ReadEvent(directory)
+	if err != nil {
+		return nil, err
+	}
+
+	repoURL := manifest.RepoURL
+	var commit string
+	if event != nil {
+		if event.Repository.HtmlURL != "" {
+			repoURL = event.Repository.HtmlURL
+		}
+
+		commit = event.After
+		if commit == "" {
+			commit = event.HeadCommit.ID
+		}
+	}
+	if repoURL == "" {
+		log.Printf("%s: skipping %s build without a repository URL", manifest.InternalName, environment)
+		return nil, nil
+	}
+	if commit == "" {
+		log.Printf("%s: %s commit is unknown and must be filled in", manifest.InternalName, environment)
+	}
+
+	if len(owners) == 0 {
+		if owner, _, ok := parseGitHubRepository(repoURL); ok {
+			owners = []string{owner}
+		}
+	}
+
+	if !strings.HasSuffix(repoURL, ".git") {
+		repoURL += ".git"
+	}
+
+	return &DIP17Manifest{
+		Repository:  repoURL,
+		Commit:      commit,
+		Owners:      owners,
+		ProjectPath: strings.ReplaceAll(projectPath, "{name}", manifest.InternalName),
+		Changelog:   changelog,
+	}, nil
+}
+
+// ExportDIP17 writes the master's plugins in the layout of goatcorp/DalamudPluginsD17: stable/<InternalName> and
+// testing/live/<InternalName>, each containing a manifest.toml.
+func ExportDIP17(manifests []*PluginManifest, output string, owners []string, projectPath string) error {
+	for _, manifest := range manifests {
+		channels := map[string]string{}
+		if !manifest.IsTestingExclusive {
+			channels["stable"] = "stable"
+		}
+		if manifest.TestingAssemblyVersion != "" {
+			channels["testing"] = filepath.Join("testing", "live")
+		}
+
+		for environment, channel := range channels {
+			changelog := manifest.Changelog
+			if environment == "testing" && manifest.TestingChangelog != "" {
+				changelog = manifest.TestingChangelog
+			}
+
+			dip17, err := buildDIP17Manifest(manifest, environment, changelog, owners, projectPath)
+			if err != nil {
+				return err
+			}
+			if dip17 == nil {
+				continue
+			}
+
+			directory := filepath.Join(output, channel, manifest.InternalName)
+			if err = os.MkdirAll(directory, 0755); err != nil {
+				return err
+			}
+			if err = os.WriteFile(filepath.Join(directory, "manifest.toml"), []byte(dip17.String()), 0644); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func exportDIP17Command(args []string) error {
+	flags := flag.NewFlagSet("export-dip17", flag.ContinueOnError)
+	output := flags.String("output", "dip17", "directory to write the DIP17 layout to")
+	owners := flags.String("owners", "", "comma-separated GitHub usernames of the owners (default: the repository owner)")
+	projectPath := flags.String("project-path", "", "path of the project in the plugin repository, where {name} is replaced with the InternalName")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return errors.New("usage: export-dip17 [-output <directory>] [-owners <users>] [-project-path <path>]")
+	}
+
+	manifests, err := ReadMaster(filepath.Join("plugins", "master.json"))
+	if err != nil {
+		return err
+	}
+	if manifests == nil {
+		return errors.New("plugins/master.json does not exist; run generate first")
+	}
+
+	var ownerList []string
+	if *owners != "" {
+		ownerList = strings.Split(*owners, ",")
+	}
+
+	return ExportDIP17(manifests, *output, ownerList, *projectPath)
+}
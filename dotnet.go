@@ -0,0 +1,372 @@
+package main
+
+import (
+	"bytes"
+	"debug/pe"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/bits"
+	"strings"
+)
+
+// ECMA-335 II.22 metadata table numbers used to locate the Assembly and AssemblyRef tables.
+const (
+	tableModule                 = 0x00
+	tableTypeRef                = 0x01
+	tableTypeDef                = 0x02
+	tableFieldPtr               = 0x03
+	tableField                  = 0x04
+	tableMethodPtr              = 0x05
+	tableMethodDef              = 0x06
+	tableParamPtr               = 0x07
+	tableParam                  = 0x08
+	tableInterfaceImpl          = 0x09
+	tableMemberRef              = 0x0A
+	tableConstant               = 0x0B
+	tableCustomAttribute        = 0x0C
+	tableFieldMarshal           = 0x0D
+	tableDeclSecurity           = 0x0E
+	tableClassLayout            = 0x0F
+	tableFieldLayout            = 0x10
+	tableStandAloneSig          = 0x11
+	tableEventMap               = 0x12
+	tableEventPtr               = 0x13
+	tableEvent                  = 0x14
+	tablePropertyMap            = 0x15
+	tablePropertyPtr            = 0x16
+	tableProperty               = 0x17
+	tableMethodSemantics        = 0x18
+	tableMethodImpl             = 0x19
+	tableModuleRef              = 0x1A
+	tableTypeSpec               = 0x1B
+	tableImplMap                = 0x1C
+	tableFieldRVA               = 0x1D
+	tableEncLog                 = 0x1E
+	tableEncMap                 = 0x1F
+	tableAssembly               = 0x20
+	tableAssemblyProcessor      = 0x21
+	tableAssemblyOS             = 0x22
+	tableAssemblyRef            = 0x23
+	tableFile                   = 0x26
+	tableExportedType           = 0x27
+	tableManifestResource       = 0x28
+	tableGenericParam           = 0x2A
+	tableMethodSpec             = 0x2B
+	tableGenericParamConstraint = 0x2C
+	tableUnused                 = -1
+)
+
+type DotNetVersion struct {
+	Major, Minor, Build, Revision uint16
+}
+
+func (v DotNetVersion) String() string {
+	return fmt.Sprintf("%d.%d.%d.%d", v.Major, v.Minor, v.Build, v.Revision)
+}
+
+type AssemblyReference struct {
+	Name    string
+	Version DotNetVersion
+}
+
+type AssemblyMetadata struct {
+	Name       string
+	Version    DotNetVersion
+	References []AssemblyReference
+}
+
+func (m *AssemblyMetadata) FindReference(name string) (AssemblyReference, bool) {
+	for _, reference := range m.References {
+		if reference.Name == name {
+			return reference, true
+		}
+	}
+
+	return AssemblyReference{}, false
+}
+
+// NormalizeVersion pads a version string to four components so "1.2.3" compares equal to "1.2.3.0".
+func NormalizeVersion(version string) string {
+	parts := strings.Split(strings.TrimSpace(version), ".")
+	for len(parts) < 4 {
+		parts = append(parts, "0")
+	}
+
+	return strings.Join(parts, ".")
+}
+
+type metadataReader struct {
+	data   []byte
+	offset int
+	err    error
+}
+
+func (r *metadataReader) read(size int) uint32 {
+	if r.err != nil {
+		return 0
+	}
+	if r.offset+size > len(r.data) {
+		r.err = errors.New("unexpected end of metadata")
+		return 0
+	}
+
+	b := r.data[r.offset : r.offset+size]
+	r.offset += size
+
+	switch size {
+	case 1:
+		return uint32(b[0])
+	case 2:
+		return uint32(binary.LittleEndian.Uint16(b))
+	default:
+		return binary.LittleEndian.Uint32(b)
+	}
+}
+
+func readCString(data []byte, offset int) string {
+	if offset >= len(data) {
+		return ""
+	}
+
+	end := bytes.IndexByte(data[offset:], 0)
+	if end < 0 {
+		return string(data[offset:])
+	}
+
+	return string(data[offset : offset+end])
+}
+
+func rvaToSlice(file *pe.File, rva, size uint32) ([]byte, error) {
+	for _, section := range file.Sections {
+		if rva < section.VirtualAddress || rva >= section.VirtualAddress+max(section.VirtualSize, section.Size) {
+			continue
+		}
+
+		data, err := section.Data()
+		if err != nil {
+			return nil, err
+		}
+
+		start := rva - section.VirtualAddress
+		if uint64(start)+uint64(size) > uint64(len(data)) {
+			return nil, errors.New("data directory exceeds section bounds")
+		}
+
+		return data[start : start+size], nil
+	}
+
+	return nil, fmt.Errorf("rva 0x%x is not mapped to any section", rva)
+}
+
+func ReadAssemblyMetadata(content []byte) (*AssemblyMetadata, error) {
+	file, err := pe.NewFile(bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var directory pe.DataDirectory
+	switch header := file.OptionalHeader.(type) {
+	case *pe.OptionalHeader32:
+		directory = header.DataDirectory[pe.IMAGE_DIRECTORY_ENTRY_COM_DESCRIPTOR]
+	case *pe.OptionalHeader64:
+		directory = header.DataDirectory[pe.IMAGE_DIRECTORY_ENTRY_COM_DESCRIPTOR]
+	default:
+		return nil, errors.New("missing optional header")
+	}
+	if directory.VirtualAddress == 0 {
+		return nil, errors.New("not a .NET assembly")
+	}
+
+	cli, err := rvaToSlice(file, directory.VirtualAddress, 16)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata, err := rvaToSlice(file, binary.LittleEndian.Uint32(cli[8:12]), binary.LittleEndian.Uint32(cli[12:16]))
+	if err != nil {
+		return nil, err
+	}
+
+	return parseMetadataRoot(metadata)
+}
+
+func parseMetadataRoot(metadata []byte) (*AssemblyMetadata, error) {
+	r := &metadataReader{data: metadata}
+	if r.read(4) != 0x424A5342 {
+		return nil, errors.New("invalid metadata signature")
+	}
+
+	r.offset += 8
+	r.offset += int(r.read(4))
+	r.offset += 2
+	count := int(r.read(2))
+
+	streams := map[string][]byte{}
+	for i := 0; i < count && r.err == nil; i++ {
+		offset, size := r.read(4), r.read(4)
+		name := readCString(metadata, r.offset)
+		r.offset += (len(name) + 4) &^ 3
+
+		if uint64(offset)+uint64(size) > uint64(len(metadata)) {
+			return nil, fmt.Errorf("stream %s exceeds metadata bounds", name)
+		}
+		streams[name] = metadata[offset : offset+size]
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	tables, ok := streams["#~"]
+	if !ok {
+		return nil, errors.New("metadata tables stream is not found")
+	}
+
+	return parseMetadataTables(tables, streams["#Strings"])
+}
+
+func parseMetadataTables(tables, stringHeap []byte) (*AssemblyMetadata, error) {
+	r := &metadataReader{data: tables}
+	r.offset += 6
+	heapSizes := r.read(1)
+	r.offset++
+	valid := uint64(r.read(4)) | uint64(r.read(4))<<32
+	r.offset += 8
+
+	var rows [64]int
+	for i := 0; i < 64; i++ {
+		if valid&(1<<i) != 0 {
+			rows[i] = int(r.read(4))
+		}
+	}
+	if heapSizes&0x20 != 0 {
+		r.offset += 4
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	heapIndex := func(flag uint32) int {
+		if heapSizes&flag != 0 {
+			return 4
+		}
+		return 2
+	}
+	stringIndex, guidIndex, blobIndex := heapIndex(0x01), heapIndex(0x02), heapIndex(0x04)
+
+	tableIndex := func(table int) int {
+		if rows[table] >= 1<<16 {
+			return 4
+		}
+		return 2
+	}
+	codedIndex := func(tables ...int) int {
+		tagBits := bits.Len(uint(len(tables) - 1))
+		for _, table := range tables {
+			if table != tableUnused && rows[table] >= 1<<(16-tagBits) {
+				return 4
+			}
+		}
+		return 2
+	}
+
+	typeDefOrRef := codedIndex(tableTypeDef, tableTypeRef, tableTypeSpec)
+	hasConstant := codedIndex(tableField, tableParam, tableProperty)
+	hasCustomAttribute := codedIndex(
+		tableMethodDef, tableField, tableTypeRef, tableTypeDef, tableParam, tableInterfaceImpl, tableMemberRef,
+		tableModule, tableDeclSecurity, tableProperty, tableEvent, tableStandAloneSig, tableModuleRef, tableTypeSpec,
+		tableAssembly, tableAssemblyRef, tableFile, tableExportedType, tableManifestResource, tableGenericParam,
+		tableGenericParamConstraint, tableMethodSpec,
+	)
+	hasFieldMarshal := codedIndex(tableField, tableParam)
+	hasDeclSecurity := codedIndex(tableTypeDef, tableMethodDef, tableAssembly)
+	memberRefParent := codedIndex(tableTypeDef, tableTypeRef, tableModuleRef, tableMethodDef, tableTypeSpec)
+	hasSemantics := codedIndex(tableEvent, tableProperty)
+	methodDefOrRef := codedIndex(tableMethodDef, tableMemberRef)
+	memberForwarded := codedIndex(tableField, tableMethodDef)
+	resolutionScope := codedIndex(tableModule, tableModuleRef, tableAssemblyRef, tableTypeRef)
+	customAttributeType := codedIndex(tableUnused, tableUnused, tableMethodDef, tableMemberRef, tableUnused)
+
+	rowSizes := map[int]int{
+		tableModule:            2 + stringIndex + guidIndex*3,
+		tableTypeRef:           resolutionScope + stringIndex*2,
+		tableTypeDef:           4 + stringIndex*2 + typeDefOrRef + tableIndex(tableField) + tableIndex(tableMethodDef),
+		tableFieldPtr:          tableIndex(tableField),
+		tableField:             2 + stringIndex + blobIndex,
+		tableMethodPtr:         tableIndex(tableMethodDef),
+		tableMethodDef:         4 + 2 + 2 + stringIndex + blobIndex + tableIndex(tableParam),
+		tableParamPtr:          tableIndex(tableParam),
+		tableParam:             2 + 2 + stringIndex,
+		tableInterfaceImpl:     tableIndex(tableTypeDef) + typeDefOrRef,
+		tableMemberRef:         memberRefParent + stringIndex + blobIndex,
+		tableConstant:          2 + hasConstant + blobIndex,
+		tableCustomAttribute:   hasCustomAttribute + customAttributeType + blobIndex,
+		tableFieldMarshal:      hasFieldMarshal + blobIndex,
+		tableDeclSecurity:      2 + hasDeclSecurity + blobIndex,
+		tableClassLayout:       2 + 4 + tableIndex(tableTypeDef),
+		tableFieldLayout:       4 + tableIndex(tableField),
+		tableStandAloneSig:     blobIndex,
+		tableEventMap:          tableIndex(tableTypeDef) + tableIndex(tableEvent),
+		tableEventPtr:          tableIndex(tableEvent),
+		tableEvent:             2 + stringIndex + typeDefOrRef,
+		tablePropertyMap:       tableIndex(tableTypeDef) + tableIndex(tableProperty),
+		tablePropertyPtr:       tableIndex(tableProperty),
+		tableProperty:          2 + stringIndex + blobIndex,
+		tableMethodSemantics:   2 + tableIndex(tableMethodDef) + hasSemantics,
+		tableMethodImpl:        tableIndex(tableTypeDef) + methodDefOrRef*2,
+		tableModuleRef:         stringIndex,
+		tableTypeSpec:          blobIndex,
+		tableImplMap:           2 + memberForwarded + stringIndex + tableIndex(tableModuleRef),
+		tableFieldRVA:          4 + tableIndex(tableField),
+		tableEncLog:            8,
+		tableEncMap:            4,
+		tableAssembly:          4 + 8 + 4 + blobIndex + stringIndex*2,
+		tableAssemblyProcessor: 4,
+		tableAssemblyOS:        12,
+		tableAssemblyRef:       8 + 4 + blobIndex + stringIndex*2 + blobIndex,
+	}
+
+	tableOffsets := map[int]int{}
+	offset := r.offset
+	for table := tableModule; table <= tableAssemblyRef; table++ {
+		tableOffsets[table] = offset
+		offset += rows[table] * rowSizes[table]
+	}
+	if offset > len(tables) {
+		return nil, errors.New("metadata tables exceed stream bounds")
+	}
+
+	readVersion := func(r *metadataReader) DotNetVersion {
+		return DotNetVersion{
+			Major:    uint16(r.read(2)),
+			Minor:    uint16(r.read(2)),
+			Build:    uint16(r.read(2)),
+			Revision: uint16(r.read(2)),
+		}
+	}
+
+	var assembly AssemblyMetadata
+	if rows[tableAssembly] == 0 {
+		return nil, errors.New("assembly table is empty")
+	}
+
+	r.offset = tableOffsets[tableAssembly] + 4
+	assembly.Version = readVersion(r)
+	r.offset += 4 + blobIndex
+	assembly.Name = readCString(stringHeap, int(r.read(stringIndex)))
+
+	for i := 0; i < rows[tableAssemblyRef]; i++ {
+		r.offset = tableOffsets[tableAssemblyRef] + i*rowSizes[tableAssemblyRef]
+		version := readVersion(r)
+		r.offset += 4 + blobIndex
+		name := readCString(stringHeap, int(r.read(stringIndex)))
+
+		assembly.References = append(assembly.References, AssemblyReference{Name: name, Version: version})
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	return &assembly, nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadAssemblyMetadata(t *testing.T) {
+	// testdata/lib1.dll is an empty net8.0 class library built with <AssemblyVersion>1.2.3.4</AssemblyVersion>.
+	content, err := os.ReadFile(filepath.Join("testdata", "lib1.dll"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	metadata, err := ReadAssemblyMetadata(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if metadata.Name != "lib1" {
+		t.Errorf("Name = %q, want %q", metadata.Name, "lib1")
+	}
+	if got := metadata.Version.String(); got != "1.2.3.4" {
+		t.Errorf("Version = %s, want 1.2.3.4", got)
+	}
+
+	reference, ok := metadata.FindReference("System.Runtime")
+	if !ok {
+		t.Fatalf("System.Runtime is not referenced: %+v", metadata.References)
+	}
+	if got := reference.Version.String(); got != "8.0.0.0" {
+		t.Errorf("System.Runtime version = %s, want 8.0.0.0", got)
+	}
+}
+
+func TestReadAssemblyMetadataInvalid(t *testing.T) {
+	content, err := os.ReadFile(filepath.Join("testdata", "lib1.dll"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		content []byte
+	}{
+		{name: "empty", content: nil},
+		{name: "not a PE file", content: []byte("PK\x03\x04 not an assembly")},
+		{name: "truncated", content: content[:512]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ReadAssemblyMetadata(tt.content); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestNormalizeVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		want    string
+	}{
+		{version: "1", want: "1.0.0.0"},
+		{version: "1.2.3", want: "1.2.3.0"},
+		{version: " 1.2.3.4 ", want: "1.2.3.4"},
+	}
+	for _, tt := range tests {
+		if got := NormalizeVersion(tt.version); got != tt.want {
+			t.Errorf("NormalizeVersion(%q) = %q, want %q", tt.version, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomEntry struct {
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Link    atomLink    `xml:"link"`
+	Author  *atomAuthor `xml:"author,omitempty"`
+	Summary string      `xml:"summary,omitempty"`
+}
+
+type atomFeed struct {
+	XMLName xml.Name     `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string       `xml:"title"`
+	ID      string       `xml:"id"`
+	Updated string       `xml:"updated"`
+	Link    []atomLink   `xml:"link"`
+	Entries []*atomEntry `xml:"entry"`
+}
+
+// latestVersion returns the version users of the plugin were offered most recently.
+func latestVersion(manifest *PluginManifest) string {
+	if manifest.TestingAssemblyVersion != "" && CompareVersions(manifest.TestingAssemblyVersion, manifest.AssemblyVersion) > 0 {
+		return manifest.TestingAssemblyVersion
+	}
+
+	return manifest.AssemblyVersion
+}
+
+// DumpFeed writes plugins/feed.xml, an Atom feed of the most recently updated plugins.
+func DumpFeed(manifests []*PluginManifest, domain string, size int) error {
+	recent := slices.DeleteFunc(slices.Clone(manifests), func(manifest *PluginManifest) bool {
+		return manifest.IsHide || manifest.LastUpdate == 0
+	})
+	slices.SortStableFunc(recent, func(a, b *PluginManifest) int {
+		return int(b.LastUpdate - a.LastUpdate)
+	})
+	if size > 0 && len(recent) > size {
+		recent = recent[:size]
+	}
+
+	feed := &atomFeed{
+		Title:   fmt.Sprintf("%s plugin updates", domain),
+		ID:      fmt.Sprintf("https://%s/plugins/master.json", domain),
+		Updated: time.Now().UTC().Format(time.RFC3339),
+		Link: []atomLink{
+			{Href: fmt.Sprintf("https://%s/plugins/feed.xml", domain), Rel: "self"},
+			{Href: fmt.Sprintf("https://%s/plugins/master.json", domain)},
+		},
+	}
+	if len(recent) > 0 {
+		feed.Updated = time.Unix(recent[0].LastUpdate, 0).UTC().Format(time.RFC3339)
+	}
+
+	for _, manifest := range recent {
+		version := latestVersion(manifest)
+
+		link := manifest.RepoURL
+		if link == "" {
+			link = fmt.Sprintf("https://%s/plugins/master.json", domain)
+		}
+
+		changelog := manifest.Changelog
+		if version == manifest.TestingAssemblyVersion && manifest.TestingChangelog != "" {
+			changelog = manifest.TestingChangelog
+		}
+
+		// Entry IDs are tag URIs (RFC 4151), dated by the release year so that they stay the same across runs.
+		updated := time.Unix(manifest.LastUpdate, 0).UTC()
+		entry := &atomEntry{
+			Title:   fmt.Sprintf("%s %s", manifest.Name, version),
+			ID:      fmt.Sprintf("tag:%s,%d:%s/%s", domain, updated.Year(), manifest.InternalName, version),
+			Updated: updated.Format(time.RFC3339),
+			Link:    atomLink{Href: link},
+			Summary: truncateText(changelog, changelogSnippetLength),
+		}
+		if manifest.Author != "" {
+			entry.Author = &atomAuthor{Name: manifest.Author}
+		}
+
+		feed.Entries = append(feed.Entries, entry)
+	}
+
+	content, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join("plugins", "feed.xml"), append([]byte(xml.Header), content...), 0644)
+}
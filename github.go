@@ -0,0 +1,167 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// maxGitHubCommits bounds the number of commits fetched for a release without a previous tag.
+const maxGitHubCommits = 30
+
+// parseGitHubRepository extracts the owner and repository name from a https://github.com/<owner>/<repo> URL.
+func parseGitHubRepository(repoURL string) (string, string, bool) {
+	u, err := url.Parse(repoURL)
+	if err != nil || !strings.EqualFold(u.Host, "github.com") {
+		return "", "", false
+	}
+
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+
+	return parts[0], strings.TrimSuffix(parts[1], ".git"), true
+}
+
+// githubRequest calls the GitHub REST API and decodes a successful response into v. Other statuses are returned
+// without an error so that callers can handle 404 themselves.
+func githubRequest(path, token string, v any) (int, error) {
+	request, err := http.NewRequest(http.MethodGet, "https://api.github.com"+path, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	request.Header.Set("User-Agent", userAgent)
+	request.Header.Set("Accept", "application/vnd.github+json")
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return 0, err
+	}
+
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return response.StatusCode, nil
+	}
+
+	return response.StatusCode, json.NewDecoder(response.Body).Decode(v)
+}
+
+// FetchLatestReleaseNotes returns the body of the latest GitHub release of the repository, or an empty string when
+// the repository has no release.
+func FetchLatestReleaseNotes(repoURL, token string) (string, error) {
+	owner, repo, ok := parseGitHubRepository(repoURL)
+	if !ok {
+		return "", nil
+	}
+
+	var release struct {
+		Body string `json:"body"`
+	}
+	status, err := githubRequest(fmt.Sprintf("/repos/%s/%s/releases/latest", owner, repo), token, &release)
+	if err != nil {
+		return "", err
+	}
+
+	switch status {
+	case http.StatusOK:
+		return strings.TrimSpace(strings.ReplaceAll(release.Body, "\r\n", "\n")), nil
+	case http.StatusNotFound:
+		return "", nil
+	default:
+		return "", fmt.Errorf("unexpected GitHub status: %d", status)
+	}
+}
+
+// findReleaseTags returns the tag of the version and the tag of the closest older version, matching tags with or
+// without a "v" prefix.
+func findReleaseTags(owner, repo, version, token string) (string, string, error) {
+	var tags []struct {
+		Name string `json:"name"`
+	}
+	status, err := githubRequest(fmt.Sprintf("/repos/%s/%s/tags?per_page=100", owner, repo), token, &tags)
+	if err != nil {
+		return "", "", err
+	}
+	if status != http.StatusOK {
+		return "", "", fmt.Errorf("unexpected GitHub status: %d", status)
+	}
+
+	var current, previous string
+	for _, tag := range tags {
+		v := strings.TrimPrefix(strings.TrimPrefix(tag.Name, "v"), "V")
+		if !versionPattern.MatchString(v) {
+			continue
+		}
+
+		switch c := CompareVersions(v, version); {
+		case c == 0:
+			current = tag.Name
+		case c < 0 && (previous == "" || CompareVersions(v, strings.TrimLeft(previous, "vV")) > 0):
+			previous = tag.Name
+		}
+	}
+
+	return current, previous, nil
+}
+
+// fetchRecentCommits lists the newest maxGitHubCommits commits reachable from the ref, newest first.
+func fetchRecentCommits(owner, repo, ref, token string) ([]*Commit, error) {
+	var commits []*Commit
+	status, err := githubRequest(fmt.Sprintf("/repos/%s/%s/commits?sha=%s&per_page=%d", owner, repo, url.QueryEscape(ref), maxGitHubCommits), token, &commits)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("unexpected GitHub status: %d", status)
+	}
+
+	return commits, nil
+}
+
+// FetchReleaseCommits lists the commits between the tag of the previous release and the tag of the version, newest
+// first like ReadLocalCommits. It returns nil when the version is not tagged in the repository.
+func FetchReleaseCommits(repoURL, version, token string) ([]*Commit, error) {
+	owner, repo, ok := parseGitHubRepository(repoURL)
+	if !ok {
+		return nil, nil
+	}
+
+	current, previous, err := findReleaseTags(owner, repo, version, token)
+	if err != nil || current == "" {
+		return nil, err
+	}
+
+	if previous == "" {
+		return fetchRecentCommits(owner, repo, current, token)
+	}
+
+	var comparison struct {
+		TotalCommits int       `json:"total_commits"`
+		Commits      []*Commit `json:"commits"`
+	}
+	status, err := githubRequest(fmt.Sprintf("/repos/%s/%s/compare/%s...%s", owner, repo, url.PathEscape(previous), url.PathEscape(current)), token, &comparison)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("unexpected GitHub status: %d", status)
+	}
+
+	// The comparison lists at most 250 commits, the oldest ones, so a longer range falls back to the newest commits
+	// of the tag. They all belong to the range as it is longer than maxGitHubCommits.
+	if comparison.TotalCommits > len(comparison.Commits) {
+		return fetchRecentCommits(owner, repo, current, token)
+	}
+
+	// The comparison lists commits oldest first.
+	slices.Reverse(comparison.Commits)
+	return comparison.Commits, nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"testing"
+)
+
+func TestFetchReleaseCommits(t *testing.T) {
+	commits := func(shas ...string) []*Commit {
+		var list []*Commit
+		for _, sha := range shas {
+			list = append(list, &Commit{SHA: sha})
+		}
+		return list
+	}
+
+	tests := []struct {
+		name       string
+		tags       []string
+		comparison any
+		want       []string
+	}{
+		{
+			name: "no previous tag",
+			tags: []string{"v2.0.0.0"},
+			want: []string{"head", "parent"},
+		},
+		{
+			name:       "comparison is reversed",
+			tags:       []string{"v2.0.0.0", "v1.0.0.0"},
+			comparison: map[string]any{"total_commits": 3, "commits": commits("old", "middle", "new")},
+			want:       []string{"new", "middle", "old"},
+		},
+		{
+			name:       "truncated comparison",
+			tags:       []string{"v2.0.0.0", "v1.0.0.0"},
+			comparison: map[string]any{"total_commits": 300, "commits": commits("old", "middle", "new")},
+			want:       []string{"head", "parent"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/repos/owner/repo/tags":
+					var tags []map[string]string
+					for _, tag := range tt.tags {
+						tags = append(tags, map[string]string{"name": tag})
+					}
+					json.NewEncoder(w).Encode(tags)
+				case "/repos/owner/repo/compare/v1.0.0.0...v2.0.0.0":
+					json.NewEncoder(w).Encode(tt.comparison)
+				case "/repos/owner/repo/commits":
+					json.NewEncoder(w).Encode(commits("head", "parent"))
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			defer server.Close()
+
+			target, err := url.Parse(server.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			original := httpClient
+			httpClient = &http.Client{Transport: &rewriteTransport{target: target}}
+			defer func() { httpClient = original }()
+
+			got, err := FetchReleaseCommits("https://github.com/owner/repo", "2.0.0.0", "")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var shas []string
+			for _, commit := range got {
+				shas = append(shas, commit.SHA)
+			}
+			if !slices.Equal(shas, tt.want) {
+				t.Errorf("FetchReleaseCommits = %v, want %v", shas, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+)
+
+// maxLocalCommits bounds the number of commits read from a local repository for a release without a previous tag.
+const maxLocalCommits = 50
+
+// ancestors collects the commit and every commit reachable from it.
+func ancestors(repository *git.Repository, hash plumbing.Hash) (map[plumbing.Hash]bool, error) {
+	seen := map[plumbing.Hash]bool{}
+	if hash.IsZero() {
+		return seen, nil
+	}
+
+	iterator, err := repository.Log(&git.LogOptions{From: hash})
+	if err != nil {
+		return nil, err
+	}
+	defer iterator.Close()
+
+	err = iterator.ForEach(func(c *object.Commit) error {
+		seen[c.Hash] = true
+		return nil
+	})
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	return seen, nil
+}
+
+// findLocalReleaseTags resolves the commits tagged with the version and with the closest older version.
+func findLocalReleaseTags(repository *git.Repository, version string) (plumbing.Hash, plumbing.Hash, error) {
+	var current, previous plumbing.Hash
+	var previousVersion string
+
+	tags, err := repository.Tags()
+	if err != nil {
+		return current, previous, err
+	}
+
+	err = tags.ForEach(func(reference *plumbing.Reference) error {
+		v := strings.TrimLeft(reference.Name().Short(), "vV")
+		if !versionPattern.MatchString(v) {
+			return nil
+		}
+
+		hash := reference.Hash()
+		if tag, err := repository.TagObject(hash); err == nil {
+			hash = tag.Target
+		}
+
+		switch c := CompareVersions(v, version); {
+		case c == 0:
+			current = hash
+		case c < 0 && (previousVersion == "" || CompareVersions(v, previousVersion) > 0):
+			previous, previousVersion = hash, v
+		}
+
+		return nil
+	})
+
+	return current, previous, err
+}
+
+// ReadLocalCommits reads the history of the plugin source at <root>/<name> from the git repository containing it.
+// Commits are listed from the tag of the version, or HEAD when it is not tagged, back to the tag of the previous
+// version.
+func ReadLocalCommits(root, name, version string) ([]*Commit, error) {
+	source, err := filepath.Abs(filepath.Join(root, name))
+	if err != nil {
+		return nil, err
+	}
+	if _, err = os.Stat(source); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	repository, err := git.PlainOpenWithOptions(source, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return nil, err
+	}
+
+	worktree, err := repository.Worktree()
+	if err != nil {
+		return nil, err
+	}
+
+	prefix, err := filepath.Rel(worktree.Filesystem.Root(), source)
+	if err != nil {
+		return nil, err
+	}
+	prefix = filepath.ToSlash(prefix)
+
+	current, previous, err := findLocalReleaseTags(repository, version)
+	if err != nil {
+		return nil, err
+	}
+	if current.IsZero() {
+		head, err := repository.Head()
+		if err != nil {
+			return nil, err
+		}
+
+		current = head.Hash()
+	}
+
+	// The previous tag itself is usually filtered out by the path filter, so the log stops at any of its ancestors.
+	released, err := ancestors(repository, previous)
+	if err != nil {
+		return nil, err
+	}
+
+	options := &git.LogOptions{From: current}
+	if prefix != "." {
+		options.PathFilter = func(path string) bool {
+			return strings.HasPrefix(path, prefix+"/")
+		}
+	}
+
+	iterator, err := repository.Log(options)
+	if err != nil {
+		return nil, err
+	}
+	defer iterator.Close()
+
+	var commits []*Commit
+	err = iterator.ForEach(func(c *object.Commit) error {
+		if released[c.Hash] || len(commits) >= maxLocalCommits {
+			return storer.ErrStop
+		}
+
+		commit := &Commit{SHA: c.Hash.String()}
+		commit.Commit.Author.Name = c.Author.Name
+		commit.Commit.Author.Email = c.Author.Email
+		commit.Commit.Message = strings.TrimSpace(c.Message)
+		for _, parent := range c.ParentHashes {
+			commit.Parents = append(commit.Parents, struct {
+				SHA string `json:"sha"`
+			}{SHA: parent.String()})
+		}
+
+		commits = append(commits, commit)
+		return nil
+	})
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	return commits, nil
+}
+
+// workingRepository opens the git repository containing the working directory. It is nil when there is none or when
+// it is a shallow clone, such as the depth 1 checkout of actions/checkout, whose history would attribute every file
+// to the HEAD commit.
+var workingRepository = sync.OnceValue(func() *git.Repository {
+	repository, err := git.PlainOpenWithOptions(".", &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return nil
+	}
+
+	shallow, err := repository.Storer.Shallow()
+	if err != nil {
+		return nil
+	}
+	if len(shallow) > 0 {
+		log.Printf("repository is a shallow clone, LastUpdate falls back to the next source")
+		return nil
+	}
+
+	return repository
+})
+
+// lastCommitTime returns the commit time of the last commit touching the file, or 0 when the file is untracked or
+// differs from HEAD, since its working copy is then newer than any commit.
+func lastCommitTime(repository *git.Repository, path string) (int64, error) {
+	worktree, err := repository.Worktree()
+	if err != nil {
+		return 0, err
+	}
+
+	absolute, err := filepath.Abs(path)
+	if err != nil {
+		return 0, err
+	}
+	relative, err := filepath.Rel(worktree.Filesystem.Root(), absolute)
+	if err != nil {
+		return 0, err
+	}
+	relative = filepath.ToSlash(relative)
+
+	head, err := repository.Head()
+	if err != nil {
+		return 0, err
+	}
+	commit, err := repository.CommitObject(head.Hash())
+	if err != nil {
+		return 0, err
+	}
+
+	// Comparing the blob hash answers the worktree status of this single file without hashing the whole tree.
+	committed, err := commit.File(relative)
+	if errors.Is(err, object.ErrFileNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	if plumbing.ComputeHash(plumbing.BlobObject, content) != committed.Hash {
+		return 0, nil
+	}
+
+	iterator, err := repository.Log(&git.LogOptions{From: head.Hash(), FileName: &relative, Order: git.LogOrderCommitterTime})
+	if err != nil {
+		return 0, err
+	}
+	defer iterator.Close()
+
+	last, err := iterator.Next()
+	if errors.Is(err, io.EOF) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return last.Committer.When.Unix(), nil
+}
+
+// detectLastUpdatedFromGit returns the commit time of the last commit touching latest.zip, or 0 when the working
+// directory is not a full git clone or latest.zip has uncommitted changes.
+func detectLastUpdatedFromGit(directory string) int64 {
+	repository := workingRepository()
+	if repository == nil {
+		return 0
+	}
+
+	timestamp, err := lastCommitTime(repository, filepath.Join(directory, "latest.zip"))
+	if err != nil {
+		return 0
+	}
+
+	return timestamp
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+func TestLastCommitTime(t *testing.T) {
+	root := t.TempDir()
+	repository, err := git.PlainInit(root, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	worktree, err := repository.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	commit := func(path, content string, when time.Time) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(root, path)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, path), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := worktree.Add(path); err != nil {
+			t.Fatal(err)
+		}
+		signature := &object.Signature{Name: "test", Email: "test@example.com", When: when}
+		if _, err := worktree.Commit("update "+path, &git.CommitOptions{Author: signature, Committer: signature}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	released := time.Unix(1700000000, 0)
+	commit("plugins/stable/Plugin/latest.zip", "zip", released)
+	commit("plugins/stable/Other/latest.zip", "other", released.Add(time.Hour))
+	if err := os.WriteFile(filepath.Join(root, "plugins/stable/Other/latest.zip"), []byte("modified"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, "plugins/stable/New"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "plugins/stable/New/latest.zip"), []byte("new"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		path string
+		want int64
+	}{
+		{name: "committed", path: "plugins/stable/Plugin/latest.zip", want: released.Unix()},
+		{name: "modified", path: "plugins/stable/Other/latest.zip", want: 0},
+		{name: "untracked", path: "plugins/stable/New/latest.zip", want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := lastCommitTime(repository, filepath.Join(root, filepath.FromSlash(tt.path)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("lastCommitTime = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+const changelogSnippetLength = 500
+
+type VersionHistoryEntry struct {
+	Version   string `json:"Version"`
+	Timestamp int64  `json:"Timestamp"`
+	Changelog string `json:"Changelog,omitempty"`
+}
+
+func truncateText(text string, length int) string {
+	runes := []rune(text)
+	if len(runes) <= length {
+		return text
+	}
+
+	return string(runes[:length-1]) + "…"
+}
+
+func ReadVersionHistory(directory string) ([]*VersionHistoryEntry, error) {
+	var history []*VersionHistoryEntry
+	if err := readOptionalJSON(filepath.Join(directory, "versions.json"), &history); err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
+
+// RecordVersionHistory prepends the version to versions.json when it has not been seen before.
+func RecordVersionHistory(directory, version, changelog string, now time.Time) error {
+	history, err := ReadVersionHistory(directory)
+	if err != nil {
+		return err
+	}
+
+	if slices.ContainsFunc(history, func(entry *VersionHistoryEntry) bool {
+		return entry.Version == version
+	}) {
+		return nil
+	}
+
+	history = append([]*VersionHistoryEntry{{
+		Version:   version,
+		Timestamp: now.Unix(),
+		Changelog: truncateText(changelog, changelogSnippetLength),
+	}}, history...)
+
+	content, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(directory, "versions.json"), content, 0644)
+}
+
+type ChangelogHistoryEntry struct {
+	Version   string `json:"Version"`
+	Changelog string `json:"Changelog"`
+}
+
+// RecordChangelogHistory keeps the full changelog of each version in changelog.json, newest first, so that release
+// notes survive commits.json being overwritten by the next release. At most limit versions are kept when positive.
+func RecordChangelogHistory(directory, version, changelog string, limit int) error {
+	path := filepath.Join(directory, "changelog.json")
+
+	var history []*ChangelogHistoryEntry
+	if err := readOptionalJSON(path, &history); err != nil {
+		return err
+	}
+
+	// An empty changelog (e.g. a failed fetch) keeps the recorded notes of the version instead of erasing them.
+	if changelog != "" {
+		history = slices.DeleteFunc(history, func(entry *ChangelogHistoryEntry) bool {
+			return entry.Version == version
+		})
+		history = append([]*ChangelogHistoryEntry{{Version: version, Changelog: changelog}}, history...)
+	}
+
+	slices.SortStableFunc(history, func(a, b *ChangelogHistoryEntry) int {
+		return CompareVersions(b.Version, a.Version)
+	})
+	if limit > 0 && len(history) > limit {
+		history = history[:limit]
+	}
+	if len(history) == 0 {
+		return nil
+	}
+
+	return writeJSON(path, history)
+}
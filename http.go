@@ -0,0 +1,79 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// httpClient is shared by all outbound requests so that connections are reused. It is replaced by NewHTTPClient
+// once the config is loaded.
+var httpClient = http.DefaultClient
+
+// rateLimitedTransport spaces out outbound requests so that they are started at a fixed interval at most.
+type rateLimitedTransport struct {
+	base     http.RoundTripper
+	interval time.Duration
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+func (t *rateLimitedTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	now := time.Now()
+	if t.next.Before(now) {
+		t.next = now
+	}
+	wait := t.next.Sub(now)
+	t.next = t.next.Add(t.interval)
+	t.mu.Unlock()
+
+	if wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+		case <-request.Context().Done():
+			return nil, request.Context().Err()
+		}
+	}
+
+	return t.base.RoundTrip(request)
+}
+
+// NewHTTPClient builds a client with the configured timeout which honors HTTP_PROXY, HTTPS_PROXY and NO_PROXY. When
+// HTTP_RATE_LIMIT is positive, all requests share a limit of that many requests per second to avoid tripping the
+// abuse protection of APIs on large repositories. HTTP_CACHE enables revalidating cached responses with ETag and
+// Last-Modified.
+func NewHTTPClient(cfg *Config) *http.Client {
+	var transport http.RoundTripper = &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   10,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+	if cfg.HTTPCache != "" {
+		transport = &cachingTransport{base: transport, directory: cfg.HTTPCache}
+	}
+	if cfg.HTTPRateLimit > 0 {
+		transport = &rateLimitedTransport{
+			base:     transport,
+			interval: time.Duration(float64(time.Second) / cfg.HTTPRateLimit),
+		}
+	}
+
+	return &http.Client{
+		Timeout:   cfg.HTTPTimeout,
+		Transport: transport,
+	}
+}
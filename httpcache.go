@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+type cachedResponse struct {
+	URL    string      `json:"URL"`
+	Header http.Header `json:"Header"`
+	Body   []byte      `json:"Body"`
+}
+
+// cachingTransport stores GET responses carrying an ETag or Last-Modified header in a directory and revalidates them
+// with conditional requests, so that unchanged resources are not downloaded again between frequent runs.
+type cachingTransport struct {
+	base      http.RoundTripper
+	directory string
+}
+
+func (t *cachingTransport) path(request *http.Request) string {
+	// Credentials are part of the key so that responses are never shared between different identities.
+	sum := sha256.Sum256([]byte(request.URL.String() + "\n" + request.Header.Get("Authorization")))
+	return filepath.Join(t.directory, hex.EncodeToString(sum[:])+".json")
+}
+
+func (t *cachingTransport) load(path string) *cachedResponse {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	var cached cachedResponse
+	if err = json.Unmarshal(content, &cached); err != nil {
+		return nil
+	}
+
+	return &cached
+}
+
+func (t *cachingTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	if request.Method != http.MethodGet || request.Header.Get("If-None-Match") != "" || request.Header.Get("If-Modified-Since") != "" {
+		return t.base.RoundTrip(request)
+	}
+
+	path := t.path(request)
+	cached := t.load(path)
+	if cached != nil {
+		request = request.Clone(request.Context())
+		if etag := cached.Header.Get("ETag"); etag != "" {
+			request.Header.Set("If-None-Match", etag)
+		}
+		if lastModified := cached.Header.Get("Last-Modified"); lastModified != "" {
+			request.Header.Set("If-Modified-Since", lastModified)
+		}
+	}
+
+	response, err := t.base.RoundTrip(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode == http.StatusNotModified && cached != nil {
+		response.Body.Close()
+
+		return &http.Response{
+			Status:        "200 OK",
+			StatusCode:    http.StatusOK,
+			Proto:         response.Proto,
+			ProtoMajor:    response.ProtoMajor,
+			ProtoMinor:    response.ProtoMinor,
+			Header:        cached.Header,
+			Body:          io.NopCloser(bytes.NewReader(cached.Body)),
+			ContentLength: int64(len(cached.Body)),
+			Request:       request,
+		}, nil
+	}
+
+	if response.StatusCode != http.StatusOK || (response.Header.Get("ETag") == "" && response.Header.Get("Last-Modified") == "") {
+		return response, nil
+	}
+
+	body, err := io.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	response.Body = io.NopCloser(bytes.NewReader(body))
+
+	if err = writeJSON(path, &cachedResponse{URL: request.URL.String(), Header: response.Header, Body: body}); err != nil {
+		log.Printf("failed to cache %s: %v", request.URL, err)
+	}
+
+	return response, nil
+}
@@ -0,0 +1,279 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	_ "golang.org/x/image/webp"
+)
+
+func fetchImage(u string) ([]byte, string, error) {
+	request, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	request.Header.Set("User-Agent", userAgent)
+
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return nil, "", err
+	}
+
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("unexpected status: %s", response.Status)
+	}
+
+	// Read one byte past the limit to tell a file of exactly the limit from a larger one.
+	content, err := io.ReadAll(io.LimitReader(response.Body, maxImageContent+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if len(content) > maxImageContent {
+		return nil, "", fmt.Errorf("image exceeds %d bytes", maxImageContent)
+	}
+
+	return content, response.Header.Get("Content-Type"), nil
+}
+
+// imageExtensions maps the formats accepted for mirroring, as reported by image.DecodeConfig, to the extensions
+// mirrored images are stored with.
+var imageExtensions = map[string]string{
+	"png":  ".png",
+	"jpeg": ".jpg",
+	"gif":  ".gif",
+	"webp": ".webp",
+}
+
+// imageExtension checks that the content is an image the hosting domain can safely serve and returns its
+// extension. The extension never comes from the URL, so that a URL such as https://example.com/x.html cannot
+// publish arbitrary HTML on the hosting domain.
+func imageExtension(content []byte, contentType string) (string, error) {
+	if mediaType, _, _ := mime.ParseMediaType(contentType); !strings.HasPrefix(mediaType, "image/") {
+		return "", fmt.Errorf("not an image: %q", contentType)
+	}
+
+	_, format, err := image.DecodeConfig(bytes.NewReader(content))
+	if err != nil {
+		return "", fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	extension, ok := imageExtensions[format]
+	if !ok {
+		return "", fmt.Errorf("unsupported image format: %s", format)
+	}
+
+	return extension, nil
+}
+
+// MirrorImage stores an externally hosted image under plugins/<environment>/<name>/images and returns its URL on the hosting
+// domain. Images already mirrored by a previous run are reused without fetching them again.
+func MirrorImage(imageURL, domain, environment, name string) (string, error) {
+	u, err := url.Parse(imageURL)
+	if err != nil {
+		return "", err
+	}
+	if u.Host == domain {
+		return imageURL, nil
+	}
+
+	directory := filepath.Join("plugins", environment, name, "images")
+	sum := sha256.Sum256([]byte(imageURL))
+	prefix := hex.EncodeToString(sum[:8])
+
+	existing, err := filepath.Glob(filepath.Join(directory, prefix+".*"))
+	if err != nil {
+		return "", err
+	}
+
+	// Only reuse files with an allowed extension, which mirrors written before the content checks may lack.
+	existing = slices.DeleteFunc(existing, func(p string) bool {
+		for _, extension := range imageExtensions {
+			if filepath.Ext(p) == extension {
+				return false
+			}
+		}
+		return true
+	})
+
+	var filename string
+	if len(existing) > 0 {
+		filename = filepath.Base(existing[0])
+	} else {
+		content, contentType, err := fetchImage(imageURL)
+		if err != nil {
+			return "", err
+		}
+
+		extension, err := imageExtension(content, contentType)
+		if err != nil {
+			return "", err
+		}
+
+		filename = prefix + extension
+		if err = os.MkdirAll(directory, 0755); err != nil {
+			return "", err
+		}
+		if err = os.WriteFile(filepath.Join(directory, filename), content, 0644); err != nil {
+			return "", err
+		}
+	}
+
+	return fmt.Sprintf("https://%s/plugins/%s/%s/images/%s", domain, environment, name, filename), nil
+}
+
+// MirrorManifestImages rewrites IconUrl and ImageUrls to mirrored copies, keeping the original URL when mirroring fails.
+func MirrorManifestImages(manifest *PluginManifest, domain, environment string) {
+	mirror := func(imageURL string) string {
+		if imageURL == "" {
+			return imageURL
+		}
+
+		mirrored, err := MirrorImage(imageURL, domain, environment, manifest.InternalName)
+		if err != nil {
+			log.Printf("%s: failed to mirror image %s: %v", manifest.InternalName, imageURL, err)
+			return imageURL
+		}
+
+		return mirrored
+	}
+
+	manifest.IconURL = mirror(manifest.IconURL)
+
+	imageURLs := make([]string, len(manifest.ImageURLs))
+	for i, imageURL := range manifest.ImageURLs {
+		imageURLs[i] = mirror(imageURL)
+	}
+	manifest.ImageURLs = imageURLs
+}
+
+// Image limits of this repository. They follow the sizes at which the Dalamud plugin installer displays icons and
+// screenshots, but are a policy of this repository rather than limits Dalamud enforces.
+const (
+	maxIconSize     = 512
+	minIconSize     = 64
+	maxImageWidth   = 730
+	maxImageHeight  = 380
+	maxImageCount   = 5
+	maxImageContent = 10 * 1024 * 1024
+)
+
+// loadImage reads an image from the hosting tree when it is served from the hosting domain, or fetches it otherwise.
+func loadImage(imageURL, domain string) ([]byte, string, error) {
+	u, err := url.Parse(imageURL)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if u.Host == domain {
+		// Only files of the hosting tree are read, never paths escaping it such as /../../etc/passwd.
+		p := filepath.FromSlash(strings.TrimPrefix(u.Path, "/"))
+		if !filepath.IsLocal(p) || !strings.HasPrefix(filepath.ToSlash(filepath.Clean(p)), "plugins/") {
+			return nil, "", fmt.Errorf("%s is outside of the hosting tree", u.Path)
+		}
+
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return nil, "", err
+		}
+
+		return content, mime.TypeByExtension(path.Ext(u.Path)), nil
+	}
+
+	return fetchImage(imageURL)
+}
+
+func checkImage(imageURL, domain string, icon bool) []string {
+	content, contentType, err := loadImage(imageURL, domain)
+	if err != nil {
+		return []string{fmt.Sprintf("%s is not available: %v", imageURL, err)}
+	}
+
+	var problems []string
+	if !strings.HasPrefix(contentType, "image/") {
+		problems = append(problems, fmt.Sprintf("%s is not an image: %q", imageURL, contentType))
+	}
+	if len(content) > maxImageContent {
+		problems = append(problems, fmt.Sprintf("%s is too large: %d bytes", imageURL, len(content)))
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(content))
+	if err != nil {
+		// Formats without a registered decoder (e.g. AVIF) are only checked by content type.
+		return problems
+	}
+
+	switch {
+	case icon && config.Width != config.Height:
+		problems = append(problems, fmt.Sprintf("%s is not square: %dx%d", imageURL, config.Width, config.Height))
+	case icon && (config.Width > maxIconSize || config.Width < minIconSize):
+		problems = append(problems, fmt.Sprintf("%s must be between %dx%[2]d and %dx%[3]d: %dx%d", imageURL, minIconSize, maxIconSize, config.Width, config.Height))
+	case !icon && (config.Width > maxImageWidth || config.Height > maxImageHeight):
+		problems = append(problems, fmt.Sprintf("%s exceeds %dx%d: %dx%d", imageURL, maxImageWidth, maxImageHeight, config.Width, config.Height))
+	}
+
+	return problems
+}
+
+// ValidateImages checks that IconUrl and ImageUrls point to images the plugin installer can display.
+func ValidateImages(manifests []*PluginManifest, domain, mode string) error {
+	switch mode {
+	case "off":
+		return nil
+	case "warn", "error":
+	default:
+		return fmt.Errorf("unknown image validation mode: %s", mode)
+	}
+
+	level := "warning"
+	if mode == "error" {
+		level = "error"
+	}
+
+	var failed []string
+	for _, manifest := range manifests {
+		var problems []string
+		if manifest.IconURL != "" {
+			problems = append(problems, checkImage(manifest.IconURL, domain, true)...)
+		}
+		if len(manifest.ImageURLs) > maxImageCount {
+			problems = append(problems, fmt.Sprintf("too many images: %d > %d", len(manifest.ImageURLs), maxImageCount))
+		}
+		for _, imageURL := range manifest.ImageURLs {
+			problems = append(problems, checkImage(imageURL, domain, false)...)
+		}
+
+		if len(problems) == 0 {
+			continue
+		}
+
+		for _, problem := range problems {
+			log.Printf("%s: %s", manifest.InternalName, problem)
+			AnnotateManifest(level, manifest.InternalName, problem)
+		}
+		failed = append(failed, manifest.InternalName)
+	}
+
+	if mode == "error" && len(failed) > 0 {
+		return fmt.Errorf("broken images in %d plugins: %s", len(failed), strings.Join(failed, ", "))
+	}
+
+	return nil
+}
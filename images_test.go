@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+
+	var buffer bytes.Buffer
+	if err := png.Encode(&buffer, image.NewRGBA(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatal(err)
+	}
+
+	return buffer.Bytes()
+}
+
+func TestImageExtension(t *testing.T) {
+	icon := testPNG(t, 64, 64)
+
+	tests := []struct {
+		name        string
+		content     []byte
+		contentType string
+		want        string
+		wantErr     bool
+	}{
+		{name: "png", content: icon, contentType: "image/png", want: ".png"},
+		{name: "png with parameters", content: icon, contentType: "image/png; charset=binary", want: ".png"},
+		{name: "html", content: []byte("<script>alert(1)</script>"), contentType: "text/html", wantErr: true},
+		{name: "html labelled as an image", content: []byte("<script>alert(1)</script>"), contentType: "image/png", wantErr: true},
+		{name: "image labelled as html", content: icon, contentType: "text/html", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := imageExtension(tt.content, tt.contentType)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got %q", got)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("got %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestMirrorImage(t *testing.T) {
+	icon := testPNG(t, 64, 64)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/icon.html":
+			// the extension of the URL must not decide the extension of the mirrored file
+			w.Header().Set("Content-Type", "image/png")
+			w.Write(icon)
+		case "/page.png":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("<script>alert(1)</script>"))
+		case "/large.png":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write(bytes.Repeat([]byte{0}, maxImageContent+1))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := httpClient
+	httpClient = server.Client()
+	defer func() { httpClient = client }()
+
+	chdir(t, t.TempDir())
+
+	mirrored, err := MirrorImage(server.URL+"/icon.html", "example.com", "stable", "Foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(mirrored, "https://example.com/plugins/stable/Foo/images/") || !strings.HasSuffix(mirrored, ".png") {
+		t.Errorf("mirrored to %s", mirrored)
+	}
+
+	for _, path := range []string{"/page.png", "/large.png"} {
+		if _, err = MirrorImage(server.URL+path, "example.com", "stable", "Bar"); err == nil {
+			t.Errorf("%s: expected an error", path)
+		}
+	}
+	if _, err = os.Stat(filepath.Join("plugins", "stable", "Bar")); !os.IsNotExist(err) {
+		t.Errorf("rejected images are written to the tree: %v", err)
+	}
+}
+
+func TestLoadImageStaysInTree(t *testing.T) {
+	chdir(t, t.TempDir())
+	if err := os.MkdirAll(filepath.Join("plugins", "stable", "Foo", "images"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join("plugins", "stable", "Foo", "images", "icon.png"), testPNG(t, 64, 64), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile("secret.txt", []byte("secret"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		url string
+		ok  bool
+	}{
+		{url: "https://example.com/plugins/stable/Foo/images/icon.png", ok: true},
+		{url: "https://example.com/secret.txt", ok: false},
+		{url: "https://example.com/plugins/../secret.txt", ok: false},
+		{url: "https://example.com/../../etc/passwd", ok: false},
+		{url: "https://example.com/%2E%2E/%2E%2E/etc/passwd", ok: false},
+	}
+	for _, tt := range tests {
+		if _, _, err := loadImage(tt.url, "example.com"); (err == nil) != tt.ok {
+			t.Errorf("loadImage(%q) = %v", tt.url, err)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ReadDownloadHistory reads the entries of plugins/stats/history.jsonl in the order they were recorded.
+func ReadDownloadHistory() ([]*DownloadHistoryEntry, error) {
+	f, err := os.Open(filepath.Join("plugins", "stats", "history.jsonl"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []*DownloadHistoryEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+
+		var entry DownloadHistoryEntry
+		if err = json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, err
+		}
+		entries = append(entries, &entry)
+	}
+
+	return entries, scanner.Err()
+}
+
+// baselineDownloads returns the counts of the newest history entry recorded at least window before now, or of the
+// oldest entry when the history is shorter than the window.
+func baselineDownloads(history []*DownloadHistoryEntry, now time.Time, window time.Duration) map[string]int64 {
+	if len(history) == 0 {
+		return nil
+	}
+
+	baseline := history[0]
+	for _, entry := range history {
+		if entry.Timestamp <= now.Add(-window).Unix() {
+			baseline = entry
+		}
+	}
+
+	return baseline.Downloads
+}
+
+type LeaderboardEntry struct {
+	Rank          int    `json:"Rank"`
+	InternalName  string `json:"InternalName"`
+	Name          string `json:"Name"`
+	DownloadCount int64  `json:"DownloadCount"`
+	Growth        int64  `json:"Growth"`
+}
+
+type Leaderboard struct {
+	GeneratedAt  int64               `json:"GeneratedAt"`
+	GrowthWindow int64               `json:"GrowthWindow"`
+	Downloads    []*LeaderboardEntry `json:"Downloads"`
+	Growth       []*LeaderboardEntry `json:"Growth"`
+}
+
+func rankLeaderboard(entries []*LeaderboardEntry, size int, key func(*LeaderboardEntry) int64) []*LeaderboardEntry {
+	ranked := make([]*LeaderboardEntry, len(entries))
+	for i, entry := range entries {
+		e := *entry
+		ranked[i] = &e
+	}
+
+	slices.SortStableFunc(ranked, func(a, b *LeaderboardEntry) int {
+		if c := key(b) - key(a); c != 0 {
+			return max(-1, min(1, int(c)))
+		}
+
+		return strings.Compare(a.InternalName, b.InternalName)
+	})
+	if size > 0 && len(ranked) > size {
+		ranked = ranked[:size]
+	}
+	for i, entry := range ranked {
+		entry.Rank = i + 1
+	}
+
+	return ranked
+}
+
+// BuildLeaderboard ranks the listed plugins by total downloads and by the downloads gained within the window, which
+// is measured against plugins/stats/history.jsonl.
+func BuildLeaderboard(manifests []*PluginManifest, now time.Time, window time.Duration, size int) (*Leaderboard, error) {
+	history, err := ReadDownloadHistory()
+	if err != nil {
+		return nil, err
+	}
+	baseline := baselineDownloads(history, now, window)
+
+	var entries []*LeaderboardEntry
+	for _, manifest := range manifests {
+		if manifest.IsHide {
+			continue
+		}
+
+		entry := &LeaderboardEntry{
+			InternalName:  manifest.InternalName,
+			Name:          manifest.Name,
+			DownloadCount: manifest.DownloadCount,
+		}
+		if previous, ok := baseline[manifest.InternalName]; ok {
+			entry.Growth = max(0, manifest.DownloadCount-previous)
+		}
+		entries = append(entries, entry)
+	}
+
+	return &Leaderboard{
+		GeneratedAt:  now.Unix(),
+		GrowthWindow: int64(window.Seconds()),
+		Downloads:    rankLeaderboard(entries, size, func(e *LeaderboardEntry) int64 { return e.DownloadCount }),
+		Growth:       rankLeaderboard(entries, size, func(e *LeaderboardEntry) int64 { return e.Growth }),
+	}, nil
+}
+
+func writeLeaderboardTable(b *strings.Builder, title string, entries []*LeaderboardEntry) {
+	fmt.Fprintf(b, "## %s\n\n", title)
+	b.WriteString("| # | Plugin | Downloads | Growth |\n")
+	b.WriteString("|---:|---|---:|---:|\n")
+	for _, entry := range entries {
+		name := markdownCellEscaper.Replace(entry.Name)
+		fmt.Fprintf(b, "| %d | %s | %d | +%d |\n", entry.Rank, name, entry.DownloadCount, entry.Growth)
+	}
+	b.WriteString("\n")
+}
+
+// DumpLeaderboard writes plugins/stats/top.json and, when markdownPath is set, the same rankings as markdown tables.
+func DumpLeaderboard(manifests []*PluginManifest, now time.Time, window time.Duration, size int, markdownPath string) error {
+	leaderboard, err := BuildLeaderboard(manifests, now, window, size)
+	if err != nil {
+		return err
+	}
+
+	if err = writeJSON(filepath.Join("plugins", "stats", "top.json"), leaderboard); err != nil {
+		return err
+	}
+
+	if markdownPath == "" {
+		return nil
+	}
+
+	var b strings.Builder
+	writeLeaderboardTable(&b, "Most downloaded plugins", leaderboard.Downloads)
+	period := window.String()
+	if window%(24*time.Hour) == 0 {
+		period = fmt.Sprintf("%d days", window/(24*time.Hour))
+	}
+	writeLeaderboardTable(&b, fmt.Sprintf("Trending plugins (last %s)", period), leaderboard.Growth)
+
+	if err = os.MkdirAll(filepath.Dir(markdownPath), 0755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(markdownPath, []byte(b.String()), 0644)
+}
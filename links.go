@@ -0,0 +1,188 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+
+	if _, err = io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
+
+// hashedZipPattern matches the zips copied by the "hash" download link mode.
+var hashedZipPattern = regexp.MustCompile(`^[0-9a-f]{64}\.zip$`)
+
+// warnUncountedDownloads notes once per run that the immutable download links bypass the download counter.
+var warnUncountedDownloads = sync.OnceFunc(func() {
+	log.Printf("download links point at zip copies, downloads are not counted by the download counter")
+})
+
+// downloadCopiesFilename lists the zip copies linked by the recent masters of a plugin, newest first.
+const downloadCopiesFilename = "download.copies"
+
+// keptDownloadCopies is the number of recently linked zip copies which survive pruning. Masters cached by clients and
+// CDNs keep linking the previous copy until they expire, so it must not disappear as soon as a release is published.
+const keptDownloadCopies = 2
+
+// pruneDownloadCopies removes the zips copied for previous releases by the "hash" and "version" modes, keeping
+// filename and the copy linked before it. Version copies double as archived releases and are kept when
+// ARCHIVE_RELEASES is set or when they are a snapshot restorable by rollback.
+func pruneDownloadCopies(cfg *Config, directory, filename string) error {
+	listPath := filepath.Join(directory, downloadCopiesFilename)
+	content, err := os.ReadFile(listPath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	kept := []string{filename}
+	for _, name := range strings.Fields(string(content)) {
+		if len(kept) < keptDownloadCopies && !slices.Contains(kept, name) {
+			kept = append(kept, name)
+		}
+	}
+	if err = os.WriteFile(listPath, []byte(strings.Join(kept, "\n")+"\n"), 0644); err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(directory)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || slices.Contains(kept, name) {
+			continue
+		}
+
+		var stale bool
+		switch cfg.DownloadLinkMode {
+		case "hash":
+			stale = hashedZipPattern.MatchString(name)
+		case "version":
+			version, ok := strings.CutSuffix(name, ".zip")
+			_, err := os.Stat(filepath.Join(directory, version+".manifest"))
+			stale = ok && versionPattern.MatchString(version) && !cfg.ArchiveReleases && os.IsNotExist(err)
+		}
+		if !stale {
+			continue
+		}
+
+		if err = os.Remove(filepath.Join(directory, name)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// BuildDownloadLink returns the download URL of a plugin zip. In the "hash" and "version" modes, latest.zip is
+// copied to a content-addressed filename so that CDN caches never serve a stale zip after an update, and the copies
+// older than the previous release are removed. These links point at the zip itself, so downloads through them are not counted
+// by the download counter.
+func BuildDownloadLink(cfg *Config, environment, name, version string) (string, error) {
+	filename := "latest.zip"
+	if cfg.EnableDownloadCounter {
+		filename = "download"
+	}
+
+	directory := filepath.Join("plugins", environment, name)
+	latest := filepath.Join(directory, "latest.zip")
+
+	switch cfg.DownloadLinkMode {
+	case "latest":
+		return AppendCacheBuster(cfg, fmt.Sprintf("https://%s/plugins/%s/%s/%s", cfg.HostingDomain, environment, name, filename), version, latest)
+	case "hash", "version":
+		if _, err := os.Stat(latest); os.IsNotExist(err) {
+			break
+		}
+
+		if cfg.DownloadLinkMode == "hash" {
+			sum, err := ComputeFileChecksum(latest)
+			if err != nil {
+				return "", err
+			}
+
+			filename = sum + ".zip"
+		} else {
+			if !versionPattern.MatchString(version) {
+				return "", fmt.Errorf("invalid version for a download link: %q", version)
+			}
+
+			filename = version + ".zip"
+		}
+
+		if err := copyFile(latest, filepath.Join(directory, filename)); err != nil {
+			return "", err
+		}
+		if err := pruneDownloadCopies(cfg, directory, filename); err != nil {
+			return "", err
+		}
+		if cfg.EnableDownloadCounter {
+			warnUncountedDownloads()
+		}
+	default:
+		return "", fmt.Errorf("unknown download link mode: %s", cfg.DownloadLinkMode)
+	}
+
+	return fmt.Sprintf("https://%s/plugins/%s/%s/%s", cfg.HostingDomain, environment, name, filename), nil
+}
+
+// AppendCacheBuster adds a query parameter derived from the release so that caches revalidate on every release.
+func AppendCacheBuster(cfg *Config, link, version, zipPath string) (string, error) {
+	var value string
+	switch cfg.CacheBusting {
+	case "", "off":
+		return link, nil
+	case "version":
+		value = version
+	case "hash":
+		if _, err := os.Stat(zipPath); os.IsNotExist(err) {
+			return link, nil
+		}
+
+		sum, err := ComputeFileChecksum(zipPath)
+		if err != nil {
+			return "", err
+		}
+
+		value = sum[:12]
+	default:
+		return "", fmt.Errorf("unknown cache busting scheme: %s", cfg.CacheBusting)
+	}
+
+	u, err := url.Parse(link)
+	if err != nil {
+		return "", err
+	}
+
+	query := u.Query()
+	query.Set(cfg.CacheBustingParameter, value)
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestBuildDownloadLinkKeepsPreviousCopy(t *testing.T) {
+	chdir(t, t.TempDir())
+	cfg := defaultConfig(t)
+	cfg.DownloadLinkMode = "hash"
+	cfg.HostingDomain = "example.com"
+
+	directory := filepath.Join("plugins", "stable", "Plugin")
+	if err := os.MkdirAll(directory, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	var filenames []string
+	for _, content := range []string{"first", "second", "third", "third"} {
+		if err := os.WriteFile(filepath.Join(directory, "latest.zip"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		link, err := BuildDownloadLink(cfg, "stable", "Plugin", "1.0.0.0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		filenames = append(filenames, filepath.Base(link))
+	}
+
+	entries, err := os.ReadDir(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var zips []string
+	for _, entry := range entries {
+		if hashedZipPattern.MatchString(entry.Name()) {
+			zips = append(zips, entry.Name())
+		}
+	}
+	// the first copy is pruned, while the previous one stays for masters cached before the third release
+	want := []string{filenames[1], filenames[2]}
+	slices.Sort(want)
+	if !slices.Equal(zips, want) {
+		t.Errorf("copies = %v, want %v", zips, want)
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var markdownCellEscaper = strings.NewReplacer("|", "\\|", "\r\n", " ", "\n", " ")
+
+// WritePluginListing writes a markdown table of the listed plugins to path, regenerated on every run so that the
+// listing never drifts from the master.
+func WritePluginListing(manifests []*PluginManifest, path string) error {
+	var b strings.Builder
+	b.WriteString("<!-- This file is generated by divination-plugin-master-generator. Do not edit it by hand. -->\n\n")
+	b.WriteString("# Plugins\n\n")
+	b.WriteString("| Name | Version | API level | Downloads | Repository |\n")
+	b.WriteString("|---|---|---:|---:|---|\n")
+	for _, manifest := range manifests {
+		if manifest.IsHide {
+			continue
+		}
+
+		version := manifest.AssemblyVersion
+		if manifest.IsTestingExclusive {
+			version += " (testing)"
+		} else if manifest.TestingAssemblyVersion != "" && manifest.TestingAssemblyVersion != manifest.AssemblyVersion {
+			version += fmt.Sprintf(" / %s (testing)", manifest.TestingAssemblyVersion)
+		}
+
+		repository := ""
+		if manifest.RepoURL != "" {
+			repository = fmt.Sprintf("[%s](%s)", markdownCellEscaper.Replace(strings.TrimPrefix(manifest.RepoURL, "https://")), manifest.RepoURL)
+		}
+
+		fmt.Fprintf(&b, "| %s | %s | %d | %d | %s |\n",
+			markdownCellEscaper.Replace(manifest.Name),
+			version,
+			manifest.DalamudApiLevel,
+			manifest.DownloadCount,
+			repository,
+		)
+	}
+
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+
+	return os.WriteFile(path, []byte(b.String()), 0644)
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+type LocalizedChangelog struct {
+	Changelog        string
+	TestingChangelog string
+}
+
+// ReadLocalizedChangelogs reads changelog.<locale>.txt and changelog.<locale>.md files in the directory, keyed by
+// locale. Markdown files are converted to plaintext when plaintext is set.
+func ReadLocalizedChangelogs(directory string, plaintext bool) (map[string]string, error) {
+	changelogs := map[string]string{}
+	for _, extension := range []string{".md", ".txt"} {
+		paths, err := filepath.Glob(filepath.Join(directory, "changelog.*"+extension))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, path := range paths {
+			locale := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(path), "changelog."), extension)
+			if locale == "" {
+				continue
+			}
+
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return nil, err
+			}
+
+			text := strings.TrimSpace(strings.ReplaceAll(string(content), "\r\n", "\n"))
+			if extension == ".md" && plaintext {
+				text = MarkdownToPlaintext(text)
+			}
+
+			changelogs[locale] = text
+		}
+	}
+
+	return changelogs, nil
+}
+
+func localizeChangelog(generated, localized, mode string) (string, error) {
+	switch {
+	case localized == "":
+		return generated, nil
+	case mode == "override" || generated == "":
+		return localized, nil
+	case mode == "append":
+		return localized + "\n\n" + generated, nil
+	default:
+		return "", fmt.Errorf("unknown changelog locale mode: %s", mode)
+	}
+}
+
+// LocalizeChangelogs attaches the per-locale changelogs of both channels to the manifest, either replacing or
+// supplementing the generated changelog depending on the mode.
+func LocalizeChangelogs(manifest *PluginManifest, stableDir, testingDir, mode string, plaintext bool) error {
+	stable, err := ReadLocalizedChangelogs(stableDir, plaintext)
+	if err != nil {
+		return err
+	}
+	testing, err := ReadLocalizedChangelogs(testingDir, plaintext)
+	if err != nil {
+		return err
+	}
+
+	manifest.localizedChangelogs = map[string]*LocalizedChangelog{}
+	for locale, text := range stable {
+		changelog, err := localizeChangelog(manifest.Changelog, text, mode)
+		if err != nil {
+			return err
+		}
+
+		manifest.localizedChangelogs[locale] = &LocalizedChangelog{Changelog: changelog, TestingChangelog: manifest.TestingChangelog}
+	}
+	for locale, text := range testing {
+		localized, ok := manifest.localizedChangelogs[locale]
+		if !ok {
+			localized = &LocalizedChangelog{Changelog: manifest.Changelog}
+			manifest.localizedChangelogs[locale] = localized
+		}
+
+		if localized.TestingChangelog, err = localizeChangelog(manifest.TestingChangelog, text, mode); err != nil {
+			return err
+		}
+		if _, ok = stable[locale]; !ok && manifest.Changelog == manifest.TestingChangelog {
+			localized.Changelog = localized.TestingChangelog
+		}
+	}
+
+	return nil
+}
+
+// DumpLocalizedMasters writes plugins/i18n/master.<locale>.json for every locale which has a localized changelog,
+// removing masters of locales which no longer exist.
+func DumpLocalizedMasters(manifests []*PluginManifest) error {
+	directory := filepath.Join("plugins", "i18n")
+
+	stale, err := filepath.Glob(filepath.Join(directory, "master.*.json"))
+	if err != nil {
+		return err
+	}
+	for _, path := range stale {
+		if err = os.Remove(path); err != nil {
+			return err
+		}
+	}
+
+	var locales []string
+	for _, manifest := range manifests {
+		for locale := range manifest.localizedChangelogs {
+			if !slices.Contains(locales, locale) {
+				locales = append(locales, locale)
+			}
+		}
+	}
+
+	for _, locale := range locales {
+		localized := make([]*PluginManifest, 0, len(manifests))
+		for _, manifest := range manifests {
+			m := *manifest
+			if changelog, ok := manifest.localizedChangelogs[locale]; ok {
+				m.Changelog = changelog.Changelog
+				m.TestingChangelog = changelog.TestingChangelog
+			}
+
+			localized = append(localized, &m)
+		}
+
+		if err = writeManifestsJSON(filepath.Join(directory, fmt.Sprintf("master.%s.json", locale)), localized, true); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -1,23 +1,133 @@
 package main
 
 import (
+	"bytes"
+	"cmp"
 	"encoding/json"
 	"fmt"
-	"io"
 	"log"
-	"net/http"
 	"os"
 	"path/filepath"
 	"slices"
-	"sort"
 	"strings"
+	"time"
 
 	"github.com/caarlos0/env/v10"
 )
 
+const userAgent = "divination-plugin-master-generator/0 (+https://github.com/SlashNephy/divination-plugin-master-generator)"
+
 type Config struct {
-	HostingDomain         string `env:"HOSTING_DOMAIN" envDefault:"xiv.starry.blue"`
-	EnableDownloadCounter bool   `env:"ENABLE_DOWNLOAD_COUNTER" envDefault:"true"`
+	HostingDomain            string        `env:"HOSTING_DOMAIN" envDefault:"xiv.starry.blue"`
+	EmitRepositoryDescriptor bool          `env:"EMIT_REPOSITORY_DESCRIPTOR"`
+	RepositoryName           string        `env:"REPOSITORY_NAME"`
+	RepositoryDescription    string        `env:"REPOSITORY_DESCRIPTION"`
+	RepositoryIconURL        string        `env:"REPOSITORY_ICON_URL"`
+	MasterFormat             string        `env:"MASTER_FORMAT" envDefault:"pretty"`
+	MasterSortKey            string        `env:"MASTER_SORT_KEY" envDefault:"InternalName"`
+	EmitMasterChecksum       bool          `env:"EMIT_MASTER_CHECKSUM"`
+	EmitMasterETag           bool          `env:"EMIT_MASTER_ETAG"`
+	PrecompressMaster        bool          `env:"PRECOMPRESS_MASTER"`
+	EmitChannelMasters       bool          `env:"EMIT_CHANNEL_MASTERS"`
+	MirrorDomains            []string      `env:"MIRROR_DOMAINS" envSeparator:","`
+	EnableDownloadCounter    bool          `env:"ENABLE_DOWNLOAD_COUNTER" envDefault:"true"`
+	HTTPTimeout              time.Duration `env:"HTTP_TIMEOUT" envDefault:"60s"`
+	HTTPRateLimit            float64       `env:"HTTP_RATE_LIMIT"`
+	HTTPCache                string        `env:"HTTP_CACHE"`
+	StatsProviders           []string      `env:"STATS_PROVIDER" envSeparator:"," envDefault:"http-json"`
+	CloudflareAPIToken       string        `env:"CLOUDFLARE_API_TOKEN"`
+	CloudflarePurge          bool          `env:"CLOUDFLARE_PURGE"`
+	CloudflareZoneID         string        `env:"CLOUDFLARE_ZONE_ID"`
+	AccessLogs               []string      `env:"ACCESS_LOGS" envSeparator:","`
+	PublishTarget            string        `env:"PUBLISH_TARGET"`
+	PublishPrune             bool          `env:"PUBLISH_PRUNE"`
+	S3Endpoint               string        `env:"S3_ENDPOINT"`
+	AWSRegion                string        `env:"AWS_REGION" envDefault:"us-east-1"`
+	AWSAccessKeyID           string        `env:"AWS_ACCESS_KEY_ID"`
+	AWSSecretAccessKey       string        `env:"AWS_SECRET_ACCESS_KEY"`
+	AWSSessionToken          string        `env:"AWS_SESSION_TOKEN"`
+	StatsURLs                []string      `env:"STATS_URL" envDefault:"https://{{ .HostingDomain }}/plugins/downloads" envSeparator:","`
+	StatsFormat              string        `env:"STATS_FORMAT" envDefault:"auto"`
+	StatsMetric              string        `env:"STATS_METRIC" envDefault:"plugin_download_count"`
+	StatsToken               string        `env:"STATS_TOKEN"`
+	StatsUsername            string        `env:"STATS_USERNAME"`
+	StatsPassword            string        `env:"STATS_PASSWORD"`
+	StatsCache               string        `env:"STATS_CACHE"`
+	StatsCacheMaxAge         time.Duration `env:"STATS_CACHE_MAX_AGE" envDefault:"168h"`
+	RecordDownloadHistory    bool          `env:"RECORD_DOWNLOAD_HISTORY"`
+	StatsFallback            string        `env:"STATS_FALLBACK" envDefault:"error"`
+	DownloadAnomalyMode      string        `env:"DOWNLOAD_ANOMALY_MODE" envDefault:"warn"`
+	DownloadJumpFactor       float64       `env:"DOWNLOAD_ANOMALY_JUMP_FACTOR" envDefault:"10"`
+	StatsRetries             int           `env:"STATS_RETRIES" envDefault:"3"`
+	StatsRetryDelay          time.Duration `env:"STATS_RETRY_DELAY" envDefault:"1s"`
+	RequiredFields           []string      `env:"REQUIRED_FIELDS" envSeparator:","`
+	StrictRequiredFields     bool          `env:"STRICT_REQUIRED_FIELDS"`
+	EmbeddedManifest         string        `env:"EMBEDDED_MANIFEST" envDefault:"off"`
+	PackageValidation        string        `env:"PACKAGE_VALIDATION" envDefault:"warn"`
+	RequireEmbeddedManifest  bool          `env:"REQUIRE_EMBEDDED_MANIFEST"`
+	CheckAssemblyVersion     bool          `env:"CHECK_ASSEMBLY_VERSION" envDefault:"true"`
+	DalamudApiLevelDetection string        `env:"DALAMUD_API_LEVEL_DETECTION" envDefault:"check"`
+	EmitChecksums            bool          `env:"EMIT_CHECKSUMS"`
+	VerifyZipIntegrity       bool          `env:"VERIFY_ZIP_INTEGRITY" envDefault:"true"`
+	MaxZipCompressedSize     int64         `env:"MAX_ZIP_COMPRESSED_SIZE" envDefault:"104857600"`
+	MaxZipUncompressedSize   int64         `env:"MAX_ZIP_UNCOMPRESSED_SIZE" envDefault:"524288000"`
+	MaxZipEntrySize          int64         `env:"MAX_ZIP_ENTRY_SIZE" envDefault:"268435456"`
+	AllowedZipExtensions     []string      `env:"ALLOWED_ZIP_EXTENSIONS" envSeparator:"," envDefault:"dll,json,pdb,xml,txt,md,png,jpg,jpeg,gif,webp"`
+	DownloadLinkMode         string        `env:"DOWNLOAD_LINK_MODE" envDefault:"latest"`
+	CacheBusting             string        `env:"CACHE_BUSTING" envDefault:"off"`
+	CacheBustingParameter    string        `env:"CACHE_BUSTING_PARAMETER" envDefault:"v"`
+	EnableDeltaUpdates       bool          `env:"ENABLE_DELTA_UPDATES"`
+	ArchiveReleases          bool          `env:"ARCHIVE_RELEASES"`
+	RecordVersionHistory     bool          `env:"RECORD_VERSION_HISTORY"`
+	RecordChangelogHistory   bool          `env:"RECORD_CHANGELOG_HISTORY"`
+	ChangelogHistoryLimit    int           `env:"CHANGELOG_HISTORY_LIMIT" envDefault:"20"`
+	EmitPluginEndpoints      bool          `env:"EMIT_PLUGIN_ENDPOINTS"`
+	EmitFeed                 bool          `env:"EMIT_FEED"`
+	EmitBadges               bool          `env:"EMIT_BADGES"`
+	EmitCatalog              bool          `env:"EMIT_CATALOG"`
+	EmitSearchIndex          bool          `env:"EMIT_SEARCH_INDEX"`
+	EmitMetadata             bool          `env:"EMIT_METADATA"`
+	PluginListing            string        `env:"PLUGIN_LISTING"`
+	EmitLeaderboard          bool          `env:"EMIT_LEADERBOARD"`
+	LeaderboardSize          int           `env:"LEADERBOARD_SIZE" envDefault:"10"`
+	LeaderboardGrowthWindow  time.Duration `env:"LEADERBOARD_GROWTH_WINDOW" envDefault:"168h"`
+	LeaderboardMarkdown      string        `env:"LEADERBOARD_MARKDOWN"`
+	EmitChanges              bool          `env:"EMIT_CHANGES"`
+	EmitMetrics              bool          `env:"EMIT_METRICS"`
+	EmitChangedPlugins       bool          `env:"EMIT_CHANGED_PLUGINS"`
+	ChangedPluginsFile       string        `env:"CHANGED_PLUGINS_FILE" envDefault:"plugins/changed.txt"`
+	FeedSize                 int           `env:"FEED_SIZE" envDefault:"20"`
+	DiscordWebhookURL        string        `env:"DISCORD_WEBHOOK_URL"`
+	SlackWebhookURL          string        `env:"SLACK_WEBHOOK_URL"`
+	MastodonServer           string        `env:"MASTODON_SERVER"`
+	MastodonAccessToken      string        `env:"MASTODON_ACCESS_TOKEN"`
+	BlueskyService           string        `env:"BLUESKY_SERVICE" envDefault:"https://bsky.social"`
+	BlueskyIdentifier        string        `env:"BLUESKY_IDENTIFIER"`
+	BlueskyPassword          string        `env:"BLUESKY_APP_PASSWORD"`
+	LastUpdateSources        []string      `env:"LAST_UPDATE_SOURCES" envSeparator:"," envDefault:"git,mtime"`
+	SigningKey               string        `env:"SIGNING_KEY"`
+	EmitDependencyInventory  bool          `env:"EMIT_DEPENDENCY_INVENTORY"`
+	MalwareScanner           string        `env:"MALWARE_SCANNER" envDefault:"off"`
+	MalwareDetectionAction   string        `env:"MALWARE_DETECTION_ACTION" envDefault:"error"`
+	VirusTotalAPIKey         string        `env:"VIRUSTOTAL_API_KEY"`
+	ExtractIcons             bool          `env:"EXTRACT_ICONS"`
+	MirrorImages             bool          `env:"MIRROR_IMAGES"`
+	ImageValidation          string        `env:"IMAGE_VALIDATION" envDefault:"off"`
+	ChangelogSource          string        `env:"CHANGELOG_SOURCE" envDefault:"commits"`
+	GitHubToken              string        `env:"GITHUB_TOKEN"`
+	ChangelogGitHubFallback  bool          `env:"CHANGELOG_GITHUB_FALLBACK"`
+	ChangelogGitSource       string        `env:"CHANGELOG_GIT_SOURCE"`
+	ChangelogStyle           string        `env:"CHANGELOG_STYLE" envDefault:"plain"`
+	ChangelogTemplate        string        `env:"CHANGELOG_TEMPLATE"`
+	ChangelogFormat          string        `env:"CHANGELOG_FORMAT" envDefault:"text"`
+	StripCommitTrailers      bool          `env:"STRIP_COMMIT_TRAILERS" envDefault:"true"`
+	ChangelogSubjectOnly     bool          `env:"CHANGELOG_SUBJECT_ONLY"`
+	ChangelogGitmoji         string        `env:"CHANGELOG_GITMOJI" envDefault:"keep"`
+	ChangelogLocaleMode      string        `env:"CHANGELOG_LOCALE_MODE" envDefault:"override"`
+	ChangelogMaxCommits      int           `env:"CHANGELOG_MAX_COMMITS"`
+	ChangelogMaxLength       int           `env:"CHANGELOG_MAX_LENGTH"`
+	ChangelogIgnoredAuthors  []string      `env:"CHANGELOG_IGNORED_AUTHORS" envDefault:"github-actions,github-actions[bot],dependabot[bot],renovate[bot]" envSeparator:","`
+	ChangelogIgnorePatterns  []string      `env:"CHANGELOG_IGNORE_PATTERNS" envDefault:"^Merge (pull request|branch|remote-tracking branch) ,\\[(skip ci|ci skip|no ci|skip actions|actions skip)\\],^Bump \\S+ from \\S+ to \\S+,^(chore|build|fix)\\(deps(-dev)?\\): ,^Update (dependency|module) " envSeparator:","`
 }
 
 func main() {
@@ -25,7 +135,56 @@ func main() {
 	if err := env.Parse(&cfg); err != nil {
 		log.Fatalf("failed to load config: %v", err)
 	}
+	cfg.AllowedZipExtensions = NormalizeZipExtensions(cfg.AllowedZipExtensions)
+
+	httpClient = NewHTTPClient(&cfg)
+
+	command := "generate"
+	if len(os.Args) > 1 {
+		command = os.Args[1]
+	}
 
+	switch command {
+	case "generate":
+		generate(&cfg)
+	case "schema":
+		if err := WriteManifestSchema(os.Stdout); err != nil {
+			log.Fatalf("failed to write schema: %v", err)
+		}
+	case "promote":
+		if err := promoteCommand(&cfg, os.Args[2:]); err != nil {
+			log.Fatalf("failed to promote: %v", err)
+		}
+	case "rollback":
+		if err := rollbackCommand(&cfg, os.Args[2:]); err != nil {
+			log.Fatalf("failed to roll back: %v", err)
+		}
+	case "keygen":
+		if err := keygenCommand(os.Stdout); err != nil {
+			log.Fatalf("failed to generate key: %v", err)
+		}
+	case "diff":
+		if err := diffCommand(&cfg, os.Args[2:], os.Stdout); err != nil {
+			log.Fatalf("failed to diff: %v", err)
+		}
+	case "apply-delta":
+		if err := applyDeltaCommand(os.Args[2:]); err != nil {
+			log.Fatalf("failed to apply delta: %v", err)
+		}
+	case "serve":
+		if err := serveCommand(os.Args[2:]); err != nil {
+			log.Fatalf("failed to serve: %v", err)
+		}
+	case "export-dip17":
+		if err := exportDIP17Command(os.Args[2:]); err != nil {
+			log.Fatalf("failed to export: %v", err)
+		}
+	default:
+		log.Fatalf("unknown command: %s", command)
+	}
+}
+
+func generate(cfg *Config) {
 	stable, err := ExtractManifests("stable")
 	if err != nil {
 		log.Fatalf("failed to extract stable manifests: %v", err)
@@ -36,50 +195,268 @@ func main() {
 		log.Fatalf("failed to extract testing manifests: %v", err)
 	}
 
-	manifests, err := MergeManifests(stable, testing, cfg.HostingDomain, cfg.EnableDownloadCounter)
+	if stable, err = ApplyEmbeddedManifests(stable, "stable", cfg.EmbeddedManifest); err != nil {
+		log.Fatalf("failed to apply embedded stable manifests: %v", err)
+	}
+
+	if testing, err = ApplyEmbeddedManifests(testing, "testing", cfg.EmbeddedManifest); err != nil {
+		log.Fatalf("failed to apply embedded testing manifests: %v", err)
+	}
+
+	if stable, err = VerifyPackages(stable, "stable", cfg); err != nil {
+		log.Fatalf("failed to verify stable packages: %v", err)
+	}
+
+	if testing, err = VerifyPackages(testing, "testing", cfg); err != nil {
+		log.Fatalf("failed to verify testing packages: %v", err)
+	}
+
+	if stable, err = ScanPackages(stable, "stable", cfg); err != nil {
+		log.Fatalf("failed to scan stable packages: %v", err)
+	}
+
+	if testing, err = ScanPackages(testing, "testing", cfg); err != nil {
+		log.Fatalf("failed to scan testing packages: %v", err)
+	}
+
+	manifests, err := MergeManifests(stable, testing, cfg)
 	if err != nil {
 		log.Fatalf("failed to merge manifests: %v", err)
 	}
 
-	if err = DumpMaster(manifests); err != nil {
+	if err = EnforceRequiredFields(manifests, cfg.RequiredFields, cfg.StrictRequiredFields); err != nil {
+		log.Fatalf("failed to validate manifests: %v", err)
+	}
+
+	if err = ValidateImages(manifests, cfg.HostingDomain, cfg.ImageValidation); err != nil {
+		log.Fatalf("failed to validate images: %v", err)
+	}
+
+	previous, err := ReadMaster(filepath.Join("plugins", "master.json"))
+	if err != nil {
+		log.Fatalf("failed to read previous master: %v", err)
+	}
+
+	if cfg.EnableDownloadCounter {
+		if err = CheckDownloadAnomalies(previous, manifests, cfg.DownloadAnomalyMode, cfg.DownloadJumpFactor); err != nil {
+			log.Fatalf("failed to check download counts: %v", err)
+		}
+	}
+
+	if err = DumpMaster(manifests, cfg.MasterFormat, cfg.MasterSortKey); err != nil {
 		log.Fatalf("failed to dump manifests: %v", err)
 	}
+
+	if cfg.EmitMasterChecksum {
+		err = WriteMasterChecksums(filepath.Join("plugins", "master.json"), cfg.EmitMasterETag)
+	} else {
+		err = RemoveMasterChecksums(filepath.Join("plugins", "master.json"))
+	}
+	if err != nil {
+		log.Fatalf("failed to write master checksum: %v", err)
+	}
+
+	if cfg.PrecompressMaster {
+		err = PrecompressFile(filepath.Join("plugins", "master.json"))
+	} else {
+		err = removePrecompressed(filepath.Join("plugins", "master.json"), precompressedExtensions...)
+	}
+	if err != nil {
+		log.Fatalf("failed to precompress master: %v", err)
+	}
+
+	if cfg.EnableDownloadCounter && cfg.RecordDownloadHistory {
+		if err = AppendDownloadHistory(manifests, time.Now()); err != nil {
+			log.Fatalf("failed to record download history: %v", err)
+		}
+	}
+
+	if cfg.EnableDownloadCounter && cfg.EmitLeaderboard {
+		if err = DumpLeaderboard(manifests, time.Now(), cfg.LeaderboardGrowthWindow, cfg.LeaderboardSize, cfg.LeaderboardMarkdown); err != nil {
+			log.Fatalf("failed to dump leaderboard: %v", err)
+		}
+	}
+
+	if cfg.EmitMetrics {
+		if err = DumpMetrics(manifests); err != nil {
+			log.Fatalf("failed to dump metrics: %v", err)
+		}
+	}
+
+	if cfg.EmitChanges {
+		diff, err := DiffMasters(previous, manifests, nil)
+		if err != nil {
+			log.Fatalf("failed to diff manifests: %v", err)
+		}
+		if err = writeJSON(filepath.Join("plugins", "changes.json"), diff); err != nil {
+			log.Fatalf("failed to write changes: %v", err)
+		}
+	}
+
+	var changed []string
+	if cfg.EmitChangedPlugins || cfg.CloudflarePurge {
+		if changed, err = DetectChangedPlugins(previous, manifests); err != nil {
+			log.Fatalf("failed to detect changed plugins: %v", err)
+		}
+	}
+
+	if cfg.EmitChangedPlugins {
+		if err = WriteChangedPlugins(changed, cfg.ChangedPluginsFile); err != nil {
+			log.Fatalf("failed to write changed plugins: %v", err)
+		}
+	}
+
+	if err = DumpLocalizedMasters(manifests); err != nil {
+		log.Fatalf("failed to dump localized manifests: %v", err)
+	}
+
+	if cfg.EmitRepositoryDescriptor {
+		if err = DumpRepositoryDescriptor(manifests, cfg); err != nil {
+			log.Fatalf("failed to dump repository descriptor: %v", err)
+		}
+	}
+
+	if cfg.EmitChannelMasters {
+		if err = DumpChannelMasters(manifests); err != nil {
+			log.Fatalf("failed to dump channel manifests: %v", err)
+		}
+	}
+
+	if len(cfg.MirrorDomains) > 0 {
+		if err = DumpMirrorMasters(manifests, cfg.HostingDomain, cfg.MirrorDomains); err != nil {
+			log.Fatalf("failed to dump mirror manifests: %v", err)
+		}
+	}
+
+	signer, err := LoadSigner(cfg.SigningKey)
+	if err != nil {
+		log.Fatalf("failed to load signing key: %v", err)
+	}
+	if signer != nil {
+		if err = signer.SignFile(filepath.Join("plugins", "master.json")); err != nil {
+			log.Fatalf("failed to sign master: %v", err)
+		}
+	}
+
+	if cfg.EmitPluginEndpoints {
+		if err = DumpPluginEndpoints(manifests); err != nil {
+			log.Fatalf("failed to dump plugin endpoints: %v", err)
+		}
+	}
+
+	if cfg.EmitFeed {
+		if err = DumpFeed(manifests, cfg.HostingDomain, cfg.FeedSize); err != nil {
+			log.Fatalf("failed to dump feed: %v", err)
+		}
+	}
+
+	if cfg.EmitBadges {
+		if err = DumpBadges(manifests); err != nil {
+			log.Fatalf("failed to dump badges: %v", err)
+		}
+	}
+
+	if cfg.EmitMetadata {
+		if err = DumpMetadata(manifests); err != nil {
+			log.Fatalf("failed to dump metadata: %v", err)
+		}
+	}
+
+	if cfg.PluginListing != "" {
+		if err = WritePluginListing(manifests, cfg.PluginListing); err != nil {
+			log.Fatalf("failed to write plugin listing: %v", err)
+		}
+	}
+
+	if cfg.EmitSearchIndex {
+		if err = DumpSearchIndex(manifests); err != nil {
+			log.Fatalf("failed to dump search index: %v", err)
+		}
+	}
+
+	if cfg.EmitCatalog {
+		if err = DumpCatalog(manifests, cfg); err != nil {
+			log.Fatalf("failed to dump catalog: %v", err)
+		}
+	}
+
+	if cfg.PublishTarget != "" {
+		if err = PublishTree(cfg, cfg.PublishTarget, cfg.PublishPrune); err != nil {
+			log.Fatalf("failed to publish: %v", err)
+		}
+	}
+
+	if cfg.CloudflarePurge {
+		urls, err := StaleURLs(previous, manifests, changed, cfg.HostingDomain)
+		if err != nil {
+			log.Fatalf("failed to list stale URLs: %v", err)
+		}
+		if err = PurgeCloudflareCache(cfg, urls); err != nil {
+			log.Printf("failed to purge Cloudflare cache: %v", err)
+		}
+	}
+
+	// The master is already published at this point, so failed notifications do not fail the run.
+	if err = NotifyUpdates(previous, manifests, cfg); err != nil {
+		log.Printf("failed to send notifications: %v", err)
+	}
 }
 
+//go:generate go run ./tools/manifestdiff
+
 type PluginManifest struct {
 	// https://github.com/goatcorp/Dalamud/blob/master/Dalamud/Plugin/Internal/Types/PluginManifest.cs
 
-	Author                 string   `json:"Author,omitempty"`
-	Name                   string   `json:"Name"`
-	Punchline              string   `json:"Punchline,omitempty"`
-	Description            string   `json:"Description,omitempty"`
-	Changelog              string   `json:"Changelog,omitempty"`
-	Tags                   []string `json:"Tags,omitempty"`
-	CategoryTags           []string `json:"CategoryTags,omitempty"`
-	IsHide                 bool     `json:"IsHide,omitempty"`
-	InternalName           string   `json:"InternalName"`
-	AssemblyVersion        string   `json:"AssemblyVersion"`
-	TestingAssemblyVersion string   `json:"TestingAssemblyVersion,omitempty"`
-	IsTestingExclusive     bool     `json:"IsTestingExclusive,omitempty"`
-	RepoURL                string   `json:"RepoUrl,omitempty"`
-	ApplicableVersion      string   `json:"ApplicableVersion,omitempty"`
-	DalamudApiLevel        int      `json:"DalamudApiLevel"`
-	DownloadCount          int64    `json:"DownloadCount,omitempty"`
-	LastUpdate             int64    `json:"LastUpdate,omitempty"`
-	DownloadLinkInstall    string   `json:"DownloadLinkInstall,omitempty"`
-	DownloadLinkUpdate     string   `json:"DownloadLinkUpdate,omitempty"`
-	DownloadLinkTesting    string   `json:"DownloadLinkTesting,omitempty"`
-	LoadRequiredState      int      `json:"LoadRequiredState,omitempty"`
-	LoadSync               bool     `json:"LoadSync,omitempty"`
-	LoadPriority           int      `json:"LoadPriority,omitempty"`
-	CanUnloadAsync         bool     `json:"CanUnloadAsync,omitempty"`
-	SupportsProfiles       bool     `json:"SupportsProfiles,omitempty"`
-	ImageURLs              []string `json:"ImageUrls,omitempty"`
-	IconURL                string   `json:"IconUrl,omitempty"`
-	AcceptsFeedback        bool     `json:"AcceptsFeedback,omitempty"`
-	FeedbackMessage        string   `json:"FeedbackMessage,omitempty"`
+	Author                       string   `json:"Author,omitempty"`
+	Authors                      []string `json:"Authors,omitempty"`
+	Name                         string   `json:"Name"`
+	Punchline                    string   `json:"Punchline,omitempty"`
+	Description                  string   `json:"Description,omitempty"`
+	Changelog                    string   `json:"Changelog,omitempty"`
+	Tags                         []string `json:"Tags,omitempty"`
+	CategoryTags                 []string `json:"CategoryTags,omitempty"`
+	IsHide                       bool     `json:"IsHide,omitempty"`
+	InternalName                 string   `json:"InternalName"`
+	AssemblyVersion              string   `json:"AssemblyVersion"`
+	TestingAssemblyVersion       string   `json:"TestingAssemblyVersion,omitempty"`
+	IsTestingExclusive           bool     `json:"IsTestingExclusive,omitempty"`
+	RepoURL                      string   `json:"RepoUrl,omitempty"`
+	ApplicableVersion            string   `json:"ApplicableVersion,omitempty"`
+	DalamudApiLevel              int      `json:"DalamudApiLevel"`
+	DownloadCount                int64    `json:"DownloadCount,omitempty"`
+	LastUpdate                   int64    `json:"LastUpdate,omitempty"`
+	DownloadLinkInstall          string   `json:"DownloadLinkInstall,omitempty"`
+	DownloadLinkUpdate           string   `json:"DownloadLinkUpdate,omitempty"`
+	DownloadLinkTesting          string   `json:"DownloadLinkTesting,omitempty"`
+	LoadRequiredState            int      `json:"LoadRequiredState,omitempty"`
+	LoadSync                     bool     `json:"LoadSync,omitempty"`
+	LoadPriority                 int      `json:"LoadPriority,omitempty"`
+	CanUnloadAsync               bool     `json:"CanUnloadAsync,omitempty"`
+	SupportsProfiles             bool     `json:"SupportsProfiles,omitempty"`
+	ImageURLs                    []string `json:"ImageUrls,omitempty"`
+	IconURL                      string   `json:"IconUrl,omitempty"`
+	AcceptsFeedback              bool     `json:"AcceptsFeedback,omitempty"`
+	FeedbackMessage              string   `json:"FeedbackMessage,omitempty"`
+	MinimumDalamudVersion        string   `json:"MinimumDalamudVersion,omitempty"`
+	TestingDalamudApiLevel       int      `json:"TestingDalamudApiLevel,omitempty"`
+	TestingChangelog             string   `json:"TestingChangelog,omitempty"`
+	Dip17Channel                 string   `json:"Dip17Channel,omitempty"`
+	Sha256                       string   `json:"Sha256,omitempty"`
+	TestingSha256                string   `json:"TestingSha256,omitempty"`
+	DeltaBaseVersion             string   `json:"DeltaBaseVersion,omitempty"`
+	DownloadLinkDelta            string   `json:"DownloadLinkDelta,omitempty"`
+	TestingDeltaBaseVersion      string   `json:"TestingDeltaBaseVersion,omitempty"`
+	DownloadLinkTestingDelta     string   `json:"DownloadLinkTestingDelta,omitempty"`
+	DownloadLinkSignature        string   `json:"DownloadLinkSignature,omitempty"`
+	DownloadLinkTestingSignature string   `json:"DownloadLinkTestingSignature,omitempty"`
+
+	localizedChangelogs map[string]*LocalizedChangelog
+	channelDownloads    *ChannelDownloads
 }
 
+// reservedFilenames are JSON files in plugin directories which are not plugin manifests.
+var reservedFilenames = []string{"commits.json", "event.json", "releases.json", "versions.json", "dependencies.json", "changelog.json"}
+
 func ExtractManifests(environment string) ([]*PluginManifest, error) {
 	var manifests []*PluginManifest
 
@@ -93,7 +470,7 @@ func ExtractManifests(environment string) ([]*PluginManifest, error) {
 			return err
 		}
 
-		if d.IsDir() || !strings.HasSuffix(d.Name(), ".json") || d.Name() == "commits.json" || d.Name() == "event.json" {
+		if d.IsDir() || !strings.HasSuffix(d.Name(), ".json") || slices.Contains(reservedFilenames, d.Name()) {
 			return nil
 		}
 
@@ -121,66 +498,174 @@ type Commit struct {
 	SHA    string `json:"sha"`
 	Commit struct {
 		Author struct {
-			Name string `json:"name"`
+			Name  string `json:"name"`
+			Email string `json:"email"`
 		} `json:"author"`
 		Message string `json:"message"`
 	} `json:"commit"`
+	Author struct {
+		Login string `json:"login"`
+	} `json:"author"`
+	Parents []struct {
+		SHA string `json:"sha"`
+	} `json:"parents"`
 }
 
-func GenerateChangelog(directory string) (string, error) {
+// ReadCommits reads commits.json in the directory. It returns nil when the file does not exist.
+func ReadCommits(directory string) ([]*Commit, error) {
 	path := filepath.Join(directory, "commits.json")
 	if _, err := os.Stat(path); os.IsNotExist(err) {
-		return "", nil
+		return nil, nil
 	}
 
 	content, err := os.ReadFile(path)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+
+	// Workflows may save either a bare commit array or the payload of the compare API ({"commits": [...]}).
+	if trimmed := bytes.TrimSpace(content); len(trimmed) > 0 && trimmed[0] == '{' {
+		var comparison struct {
+			Commits []*Commit `json:"commits"`
+		}
+		if err = json.Unmarshal(trimmed, &comparison); err != nil {
+			return nil, err
+		}
+
+		return comparison.Commits, nil
 	}
 
 	var commits []*Commit
 	if err = json.Unmarshal(content, &commits); err != nil {
+		return nil, err
+	}
+
+	return commits, nil
+}
+
+func GenerateChangelog(directory string, manifest *PluginManifest, cfg *Config) (string, error) {
+	if manifest == nil {
+		return "", nil
+	}
+
+	repoURL, err := DetectRepositoryURL(directory)
+	if err != nil {
 		return "", err
 	}
+	if repoURL == "" {
+		repoURL = manifest.RepoURL
+	}
 
-	var lines []string
-	for _, commit := range commits {
-		if commit.Commit.Author.Name == "github-actions" {
-			continue
+	switch cfg.ChangelogSource {
+	case "commits":
+	case "releases":
+		notes, err := FetchLatestReleaseNotes(repoURL, cfg.GitHubToken)
+		if err != nil {
+			log.Printf("%s: failed to fetch release notes: %v", manifest.InternalName, err)
+		}
+		if notes != "" && cfg.ChangelogFormat == "text" {
+			return MarkdownToPlaintext(notes), nil
+		}
+		if notes != "" {
+			return notes, nil
+		}
+	default:
+		return "", fmt.Errorf("unknown changelog source: %s", cfg.ChangelogSource)
+	}
+
+	commits, err := ReadCommits(directory)
+	if err != nil {
+		return "", err
+	}
+	if commits == nil && cfg.ChangelogGitSource != "" {
+		if commits, err = ReadLocalCommits(cfg.ChangelogGitSource, manifest.InternalName, manifest.AssemblyVersion); err != nil {
+			log.Printf("%s: failed to read commits from git: %v", manifest.InternalName, err)
+		}
+	}
+	if commits == nil && cfg.ChangelogGitHubFallback {
+		if commits, err = FetchReleaseCommits(repoURL, manifest.AssemblyVersion, cfg.GitHubToken); err != nil {
+			log.Printf("%s: failed to fetch commits from GitHub: %v", manifest.InternalName, err)
 		}
+	}
+	if commits == nil {
+		return "", nil
+	}
 
-		lines = append(lines, fmt.Sprintf("%s: %s", commit.SHA[0:7], commit.Commit.Message))
+	if commits, err = FilterCommits(commits, cfg); err != nil {
+		return "", err
 	}
 
-	return strings.Join(lines, "\n"), nil
+	return FormatChangelog(commits, manifest.AssemblyVersion, repoURL, cfg)
 }
 
 type Event struct {
 	Repository struct {
 		HtmlURL string `json:"html_url"`
 	} `json:"repository"`
+	After      string `json:"after"`
+	HeadCommit struct {
+		ID string `json:"id"`
+	} `json:"head_commit"`
 }
 
-func DetectRepositoryURL(directory string) (string, error) {
+// ReadEvent reads event.json, the payload of the workflow run which built the plugin, or returns nil without it.
+func ReadEvent(directory string) (*Event, error) {
 	path := filepath.Join(directory, "event.json")
 	if _, err := os.Stat(path); os.IsNotExist(err) {
-		return "", nil
+		return nil, nil
 	}
 
 	content, err := os.ReadFile(path)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	var event Event
 	if err = json.Unmarshal(content, &event); err != nil {
+		return nil, err
+	}
+
+	return &event, nil
+}
+
+func DetectRepositoryURL(directory string) (string, error) {
+	event, err := ReadEvent(directory)
+	if err != nil || event == nil {
 		return "", err
 	}
 
 	return event.Repository.HtmlURL, nil
 }
 
-func DetectLastUpdated(directory string) int64 {
+func DetectLastUpdated(directory string, sources []string) (int64, error) {
+	for _, source := range sources {
+		var timestamp int64
+		var err error
+		switch source {
+		case "mtime":
+			timestamp = detectLastUpdatedFromModTime(directory)
+		case "zip":
+			timestamp, err = detectLastUpdatedFromZip(directory)
+		case "file":
+			timestamp, err = detectLastUpdatedFromFile(directory)
+		case "git":
+			timestamp = detectLastUpdatedFromGit(directory)
+		default:
+			return 0, fmt.Errorf("unknown LastUpdate source: %s", source)
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		if timestamp > 0 {
+			return timestamp, nil
+		}
+	}
+
+	return 0, nil
+}
+
+func detectLastUpdatedFromModTime(directory string) int64 {
 	path := filepath.Join(directory, "latest.zip")
 
 	info, err := os.Stat(path)
@@ -191,35 +676,15 @@ func DetectLastUpdated(directory string) int64 {
 	return info.ModTime().Unix()
 }
 
-func FetchDownloadStatistics(domain string) (map[string]int64, error) {
-	url := fmt.Sprintf("https://%s/plugins/downloads", domain)
-	request, err := http.NewRequest(http.MethodGet, url, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	request.Header.Set("User-Agent", "divination-plugin-master-generator/0 (+https://github.com/SlashNephy/divination-plugin-master-generator)")
-
-	response, err := http.DefaultClient.Do(request)
-	if err != nil {
-		return nil, err
-	}
-
-	defer response.Body.Close()
-	content, err := io.ReadAll(response.Body)
+func MergeManifests(stable, testing []*PluginManifest, cfg *Config) ([]*PluginManifest, error) {
+	denylist, err := LoadDenylist()
 	if err != nil {
 		return nil, err
 	}
 
-	statistics := map[string]int64{}
-	if err = json.Unmarshal(content, &statistics); err != nil {
-		return nil, err
-	}
-
-	return statistics, nil
-}
+	stable = FilterBannedManifests(stable, denylist, "stable")
+	testing = FilterBannedManifests(testing, denylist, "testing")
 
-func MergeManifests(stable, testing []*PluginManifest, domain string, enableDownloadCounter bool) ([]*PluginManifest, error) {
 	stableMap := map[string]*PluginManifest{}
 	for _, manifest := range stable {
 		if _, ok := stableMap[manifest.InternalName]; ok {
@@ -250,13 +715,59 @@ func MergeManifests(stable, testing []*PluginManifest, domain string, enableDown
 		names = append(names, name)
 	}
 
+	renames, err := LoadRenames()
+	if err != nil {
+		return nil, err
+	}
+
+	signer, err := LoadSigner(cfg.SigningKey)
+	if err != nil {
+		return nil, err
+	}
+	if signer != nil {
+		if err = signer.WritePublicKey(); err != nil {
+			return nil, err
+		}
+	}
+
+	hidden, err := LoadHiddenPlugins()
+	if err != nil {
+		return nil, err
+	}
+
+	authorAliases, err := LoadAuthorAliases()
+	if err != nil {
+		return nil, err
+	}
+
 	var downloads map[string]int64
-	if enableDownloadCounter {
-		var err error
-		downloads, err = FetchDownloadStatistics(domain)
+	var channelDownloads map[string]*ChannelDownloads
+	if cfg.EnableDownloadCounter {
+		aliases, err := LoadDownloadAliases()
 		if err != nil {
 			return nil, err
 		}
+		for oldName, newName := range renames {
+			if _, ok := aliases[oldName]; !ok {
+				aliases[oldName] = newName
+			}
+		}
+
+		repositories := map[string]string{}
+		for _, name := range names {
+			if manifest, ok := testingMap[name]; ok && manifest.RepoURL != "" {
+				repositories[name] = manifest.RepoURL
+			} else if manifest, ok := stableMap[name]; ok {
+				repositories[name] = manifest.RepoURL
+			}
+		}
+
+		statistics, err := LoadDownloadStatistics(cfg, aliases, repositories, names)
+		if err != nil {
+			return nil, err
+		}
+
+		downloads, channelDownloads = SummarizeDownloads(statistics)
 	}
 
 	manifests := []*PluginManifest{}
@@ -274,20 +785,29 @@ func MergeManifests(stable, testing []*PluginManifest, domain string, enableDown
 		}
 
 		// Changelog
+		changelogs := map[string]string{}
 		{
-			t, err := GenerateChangelog(testingDir)
+			s, err := GenerateChangelog(stableDir, stableManifest, cfg)
 			if err != nil {
 				return nil, err
 			}
-			if t != "" {
+			t, err := GenerateChangelog(testingDir, testingManifest, cfg)
+			if err != nil {
+				return nil, err
+			}
+			changelogs["stable"], changelogs["testing"] = s, t
+
+			// Clients which do not read TestingChangelog still get the testing changelog when there is no stable one.
+			manifest.Changelog = s
+			if s == "" {
 				manifest.Changelog = t
-			} else {
-				s, err := GenerateChangelog(stableDir)
-				if err != nil {
-					return nil, err
-				}
+			}
+			if testingManifest != nil {
+				manifest.TestingChangelog = t
+			}
 
-				manifest.Changelog = s
+			if err = LocalizeChangelogs(&manifest, stableDir, testingDir, cfg.ChangelogLocaleMode, cfg.ChangelogFormat == "text"); err != nil {
+				return nil, fmt.Errorf("%s: failed to localize changelog: %w", name, err)
 			}
 		}
 
@@ -309,46 +829,287 @@ func MergeManifests(stable, testing []*PluginManifest, domain string, enableDown
 			}
 		}
 
+		NormalizeAuthors(&manifest, authorAliases)
+
+		if slices.Contains(hidden, name) {
+			manifest.IsHide = true
+		}
+
 		manifest.IsTestingExclusive = stableManifest == nil
-		manifest.LastUpdate = max(DetectLastUpdated(stableDir), DetectLastUpdated(testingDir))
+		// LastUpdate
+		{
+			s, err := DetectLastUpdated(stableDir, cfg.LastUpdateSources)
+			if err != nil {
+				return nil, err
+			}
+			t, err := DetectLastUpdated(testingDir, cfg.LastUpdateSources)
+			if err != nil {
+				return nil, err
+			}
 
-		var filename string
-		if enableDownloadCounter {
-			filename = "download"
-		} else {
-			filename = "latest.zip"
+			manifest.LastUpdate = max(s, t)
+		}
+
+		// IconUrl
+		if cfg.ExtractIcons && manifest.IconURL == "" {
+			environment := "stable"
+			if stableManifest == nil {
+				environment = "testing"
+			}
+
+			found, err := ExtractIcon(filepath.Join("plugins", environment, name))
+			if err != nil {
+				return nil, err
+			}
+			if found {
+				manifest.IconURL = fmt.Sprintf("https://%s/plugins/%s/%s/images/icon.png", cfg.HostingDomain, environment, name)
+			}
+		}
+
+		for environment, m := range map[string]*PluginManifest{"stable": stableManifest, "testing": testingManifest} {
+			if m == nil {
+				continue
+			}
+
+			directory := filepath.Join("plugins", environment, name)
+			if cfg.ArchiveReleases {
+				if err = ArchiveRelease(directory, m.AssemblyVersion); err != nil {
+					return nil, err
+				}
+				if err = WriteReleases(cfg.HostingDomain, environment, name); err != nil {
+					return nil, err
+				}
+			}
+
+			if cfg.EmitDependencyInventory {
+				if err = WriteDependencyInventory(directory, name); err != nil {
+					return nil, fmt.Errorf("%s: failed to write dependency inventory: %w", name, err)
+				}
+			}
+
+			if cfg.RecordVersionHistory {
+				if err = RecordVersionHistory(directory, m.AssemblyVersion, changelogs[environment], time.Now()); err != nil {
+					return nil, err
+				}
+			}
+			if cfg.RecordChangelogHistory {
+				if err = RecordChangelogHistory(directory, m.AssemblyVersion, changelogs[environment], cfg.ChangelogHistoryLimit); err != nil {
+					return nil, err
+				}
+			}
 		}
 
 		if stableManifest != nil {
 			manifest.AssemblyVersion = stableManifest.AssemblyVersion
-			manifest.DownloadLinkInstall = fmt.Sprintf("https://%s/plugins/stable/%s/%s", domain, name, filename)
+			manifest.DalamudApiLevel = stableManifest.DalamudApiLevel
+			manifest.MinimumDalamudVersion = stableManifest.MinimumDalamudVersion
+			if manifest.DownloadLinkInstall, err = BuildDownloadLink(cfg, "stable", name, stableManifest.AssemblyVersion); err != nil {
+				return nil, err
+			}
+			if manifest.DownloadLinkUpdate != "" {
+				if manifest.DownloadLinkUpdate, err = AppendCacheBuster(cfg, manifest.DownloadLinkUpdate, stableManifest.AssemblyVersion, filepath.Join(stableDir, "latest.zip")); err != nil {
+					return nil, err
+				}
+			}
 		}
 		if testingManifest != nil {
 			manifest.TestingAssemblyVersion = testingManifest.AssemblyVersion
-			manifest.DownloadLinkTesting = fmt.Sprintf("https://%s/plugins/testing/%s/%s", domain, name, filename)
+			manifest.TestingDalamudApiLevel = testingManifest.DalamudApiLevel
+			if manifest.DownloadLinkTesting, err = BuildDownloadLink(cfg, "testing", name, testingManifest.AssemblyVersion); err != nil {
+				return nil, err
+			}
 		}
 
-		if enableDownloadCounter {
+		if err = ExpandManifestTemplates(&manifest, cfg.HostingDomain); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		if cfg.MirrorImages {
+			environment := "stable"
+			if stableManifest == nil {
+				environment = "testing"
+			}
+
+			MirrorManifestImages(&manifest, cfg.HostingDomain, environment)
+		}
+
+		if cfg.EmitChecksums {
+			if stableManifest != nil {
+				if manifest.Sha256, err = WriteZipChecksum(stableDir); err != nil {
+					return nil, err
+				}
+			}
+			if testingManifest != nil {
+				if manifest.TestingSha256, err = WriteZipChecksum(testingDir); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		if signer != nil {
+			if stableManifest != nil {
+				signed, err := signer.SignPluginZip(stableDir)
+				if err != nil {
+					return nil, err
+				}
+				if signed {
+					manifest.DownloadLinkSignature = fmt.Sprintf("https://%s/plugins/stable/%s/latest.zip.minisig", cfg.HostingDomain, name)
+				}
+			}
+			if testingManifest != nil {
+				signed, err := signer.SignPluginZip(testingDir)
+				if err != nil {
+					return nil, err
+				}
+				if signed {
+					manifest.DownloadLinkTestingSignature = fmt.Sprintf("https://%s/plugins/testing/%s/latest.zip.minisig", cfg.HostingDomain, name)
+				}
+			}
+		}
+
+		if cfg.EnableDeltaUpdates {
+			if stableManifest != nil {
+				delta, err := UpdateDelta(stableDir, stableManifest.AssemblyVersion)
+				if err != nil {
+					return nil, err
+				}
+				if delta != nil {
+					manifest.DeltaBaseVersion = delta.BaseVersion
+					manifest.DownloadLinkDelta = fmt.Sprintf("https://%s/plugins/stable/%s/delta/%s", cfg.HostingDomain, name, delta.Filename)
+				}
+			}
+			if testingManifest != nil {
+				delta, err := UpdateDelta(testingDir, testingManifest.AssemblyVersion)
+				if err != nil {
+					return nil, err
+				}
+				if delta != nil {
+					manifest.TestingDeltaBaseVersion = delta.BaseVersion
+					manifest.DownloadLinkTestingDelta = fmt.Sprintf("https://%s/plugins/testing/%s/delta/%s", cfg.HostingDomain, name, delta.Filename)
+				}
+			}
+		}
+
+		// Dip17Channel
+		if manifest.Dip17Channel == "" {
+			if stableManifest != nil {
+				manifest.Dip17Channel = "stable"
+			} else {
+				manifest.Dip17Channel = "testing-live"
+			}
+		}
+
+		if cfg.EnableDownloadCounter {
 			manifest.DownloadCount, _ = downloads[name]
+			manifest.channelDownloads = channelDownloads[name]
 		}
 
 		manifests = append(manifests, &manifest)
 	}
 
-	return manifests, nil
+	manifests = AddRenameRedirects(manifests, renames)
+
+	retired, err := LoadRetiredPlugins()
+	if err != nil {
+		return nil, err
+	}
+	if len(retired) > 0 {
+		previous, err := ReadMaster(filepath.Join("plugins", "master.json"))
+		if err != nil {
+			return nil, err
+		}
+
+		manifests = RetireManifests(manifests, retired, previous)
+	}
+
+	return slices.DeleteFunc(manifests, func(manifest *PluginManifest) bool {
+		_, ok := IsBanned(denylist, manifest.InternalName, "")
+		return ok
+	}), nil
 }
 
-func DumpMaster(manifests []*PluginManifest) error {
+// DumpMaster writes plugins/master.json in the order of sortKey. The format is "pretty" for indented JSON, "minified" to cut the payload of
+// every client fetch, or "both" to write a minified master.json and an indented master.pretty.json for humans.
+func DumpMaster(manifests []*PluginManifest, format, sortKey string) error {
+	if err := ValidateMaster(manifests); err != nil {
+		return err
+	}
+
 	path := filepath.Join("plugins", "master.json")
+	prettyPath := filepath.Join("plugins", "master.pretty.json")
+
+	if err := SortManifests(manifests, sortKey); err != nil {
+		return err
+	}
+
+	switch format {
+	case "pretty":
+		if err := os.Remove(prettyPath); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		return writeManifestsJSON(path, manifests, true)
+	case "minified":
+		if err := os.Remove(prettyPath); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		return writeManifestsJSON(path, manifests, false)
+	case "both":
+		if err := writeManifestsJSON(path, manifests, false); err != nil {
+			return err
+		}
+
+		return writeManifestsJSON(prettyPath, manifests, true)
+	default:
+		return fmt.Errorf("unknown master format: %s", format)
+	}
+}
 
-	sort.Slice(manifests, func(i, j int) bool {
-		return manifests[i].InternalName < manifests[j].InternalName
+// SortManifests orders the manifests by InternalName, Name, LastUpdate (newest first) or DownloadCount (most
+// downloaded first), falling back to InternalName for ties.
+func SortManifests(manifests []*PluginManifest, key string) error {
+	var compare func(a, b *PluginManifest) int
+	switch key {
+	case "InternalName":
+		compare = func(a, b *PluginManifest) int { return 0 }
+	case "Name":
+		compare = func(a, b *PluginManifest) int {
+			return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+		}
+	case "LastUpdate":
+		compare = func(a, b *PluginManifest) int { return cmp.Compare(b.LastUpdate, a.LastUpdate) }
+	case "DownloadCount":
+		compare = func(a, b *PluginManifest) int { return cmp.Compare(b.DownloadCount, a.DownloadCount) }
+	default:
+		return fmt.Errorf("unknown sort key: %s", key)
+	}
+
+	slices.SortFunc(manifests, func(a, b *PluginManifest) int {
+		if c := compare(a, b); c != 0 {
+			return c
+		}
+
+		return strings.Compare(a.InternalName, b.InternalName)
 	})
 
-	content, err := json.MarshalIndent(manifests, "", "  ")
+	return nil
+}
+
+func ReadMaster(path string) ([]*PluginManifest, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	content, err := os.ReadFile(path)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return os.WriteFile(path, content, 0644)
+	var manifests []*PluginManifest
+	if err = json.Unmarshal(content, &manifests); err != nil {
+		return nil, err
+	}
+
+	return manifests, nil
 }
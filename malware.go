@@ -0,0 +1,298 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type MalwareScanner interface {
+	// Scan returns a non-empty detection summary when the file is considered malicious.
+	Scan(path, sum string) (string, error)
+}
+
+func NewMalwareScanner(cfg *Config) (MalwareScanner, error) {
+	switch cfg.MalwareScanner {
+	case "off":
+		return nil, nil
+	case "clamav":
+		return &ClamAVScanner{}, nil
+	case "virustotal":
+		if cfg.VirusTotalAPIKey == "" {
+			return nil, errors.New("VIRUSTOTAL_API_KEY is required for the virustotal scanner")
+		}
+
+		return &VirusTotalScanner{apiKey: cfg.VirusTotalAPIKey}, nil
+	default:
+		return nil, fmt.Errorf("unknown malware scanner: %s", cfg.MalwareScanner)
+	}
+}
+
+type ClamAVScanner struct{}
+
+func (s *ClamAVScanner) Scan(path, _ string) (string, error) {
+	output, err := exec.Command("clamscan", "--no-summary", "--infected", path).Output()
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return "", nil
+	case errors.As(err, &exitErr) && exitErr.ExitCode() == 1:
+		return strings.TrimSpace(string(output)), nil
+	default:
+		return "", fmt.Errorf("clamscan failed: %w", err)
+	}
+}
+
+type VirusTotalScanner struct {
+	apiKey string
+}
+
+type virusTotalStats struct {
+	Malicious  int `json:"malicious"`
+	Suspicious int `json:"suspicious"`
+}
+
+func (s *VirusTotalScanner) request(method, url string, body io.Reader, contentType string, v any) (int, error) {
+	request, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return 0, err
+	}
+
+	request.Header.Set("User-Agent", userAgent)
+	request.Header.Set("x-apikey", s.apiKey)
+	if contentType != "" {
+		request.Header.Set("Content-Type", contentType)
+	}
+
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return 0, err
+	}
+
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return response.StatusCode, nil
+	}
+
+	return response.StatusCode, json.NewDecoder(response.Body).Decode(v)
+}
+
+func (s *VirusTotalScanner) lookup(sum string) (*virusTotalStats, error) {
+	var report struct {
+		Data struct {
+			Attributes struct {
+				LastAnalysisStats virusTotalStats `json:"last_analysis_stats"`
+			} `json:"attributes"`
+		} `json:"data"`
+	}
+
+	status, err := s.request(http.MethodGet, "https://www.virustotal.com/api/v3/files/"+sum, nil, "", &report)
+	if err != nil {
+		return nil, err
+	}
+
+	switch status {
+	case http.StatusOK:
+		return &report.Data.Attributes.LastAnalysisStats, nil
+	case http.StatusNotFound:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unexpected VirusTotal status: %d", status)
+	}
+}
+
+// virusTotalDirectUploadLimit is the largest file accepted by POST /files. Larger files must be posted to a URL
+// obtained from GET /files/upload_url.
+const virusTotalDirectUploadLimit = 32 << 20
+
+func (s *VirusTotalScanner) uploadURL(size int64) (string, error) {
+	if size <= virusTotalDirectUploadLimit {
+		return "https://www.virustotal.com/api/v3/files", nil
+	}
+
+	var result struct {
+		Data string `json:"data"`
+	}
+	status, err := s.request(http.MethodGet, "https://www.virustotal.com/api/v3/files/upload_url", nil, "", &result)
+	if err != nil {
+		return "", err
+	}
+	if status != http.StatusOK {
+		return "", fmt.Errorf("unexpected VirusTotal status: %d", status)
+	}
+
+	return result.Data, nil
+}
+
+func (s *VirusTotalScanner) upload(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	url, err := s.uploadURL(info.Size())
+	if err != nil {
+		return "", err
+	}
+
+	// stream the multipart body so that large packages are not held in memory
+	reader, writer := io.Pipe()
+	defer reader.Close()
+	form := multipart.NewWriter(writer)
+	go func() {
+		part, err := form.CreateFormFile("file", filepath.Base(path))
+		if err == nil {
+			_, err = io.Copy(part, file)
+		}
+		if err == nil {
+			err = form.Close()
+		}
+		writer.CloseWithError(err)
+	}()
+
+	var result struct {
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	status, err := s.request(http.MethodPost, url, reader, form.FormDataContentType(), &result)
+	if err != nil {
+		return "", err
+	}
+	if status != http.StatusOK {
+		return "", fmt.Errorf("unexpected VirusTotal status: %d", status)
+	}
+
+	return result.Data.ID, nil
+}
+
+func (s *VirusTotalScanner) wait(id string) (*virusTotalStats, error) {
+	for attempt := 0; attempt < 20; attempt++ {
+		time.Sleep(15 * time.Second)
+
+		var analysis struct {
+			Data struct {
+				Attributes struct {
+					Status string          `json:"status"`
+					Stats  virusTotalStats `json:"stats"`
+				} `json:"attributes"`
+			} `json:"data"`
+		}
+		status, err := s.request(http.MethodGet, "https://www.virustotal.com/api/v3/analyses/"+id, nil, "", &analysis)
+		if err != nil {
+			return nil, err
+		}
+		if status != http.StatusOK {
+			return nil, fmt.Errorf("unexpected VirusTotal status: %d", status)
+		}
+
+		if analysis.Data.Attributes.Status == "completed" {
+			return &analysis.Data.Attributes.Stats, nil
+		}
+	}
+
+	return nil, fmt.Errorf("VirusTotal analysis %s did not complete in time", id)
+}
+
+func (s *VirusTotalScanner) Scan(path, sum string) (string, error) {
+	stats, err := s.lookup(sum)
+	if err != nil {
+		return "", err
+	}
+
+	if stats == nil {
+		id, err := s.upload(path)
+		if err != nil {
+			return "", err
+		}
+
+		if stats, err = s.wait(id); err != nil {
+			return "", err
+		}
+	}
+
+	if stats.Malicious > 0 || stats.Suspicious > 0 {
+		return fmt.Sprintf("VirusTotal: %d malicious, %d suspicious", stats.Malicious, stats.Suspicious), nil
+	}
+
+	return "", nil
+}
+
+// ScanPackages runs the configured malware scanner on every latest.zip. Zips whose SHA256 has already been scanned
+// clean are recorded in plugins/scanned.json and skipped on later runs.
+func ScanPackages(manifests []*PluginManifest, environment string, cfg *Config) ([]*PluginManifest, error) {
+	scanner, err := NewMalwareScanner(cfg)
+	if err != nil || scanner == nil {
+		return manifests, err
+	}
+
+	if cfg.MalwareDetectionAction != "error" && cfg.MalwareDetectionAction != "skip" {
+		return nil, fmt.Errorf("unknown malware detection action: %s", cfg.MalwareDetectionAction)
+	}
+
+	cachePath := filepath.Join("plugins", "scanned.json")
+	scanned := map[string]string{}
+	if err = readOptionalJSON(cachePath, &scanned); err != nil {
+		return nil, err
+	}
+
+	var clean []*PluginManifest
+	var infected []string
+	for _, manifest := range manifests {
+		path := filepath.Join("plugins", environment, manifest.InternalName, "latest.zip")
+		if _, err = os.Stat(path); os.IsNotExist(err) {
+			clean = append(clean, manifest)
+			continue
+		}
+
+		sum, err := ComputeFileChecksum(path)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := scanned[sum]; ok {
+			clean = append(clean, manifest)
+			continue
+		}
+
+		detection, err := scanner.Scan(path, sum)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to scan %s package: %w", manifest.InternalName, environment, err)
+		}
+		if detection != "" {
+			message := fmt.Sprintf("%s package is flagged as malicious: %s", environment, detection)
+			log.Printf("%s: %s", manifest.InternalName, message)
+			AnnotateManifest("error", manifest.InternalName, message)
+			infected = append(infected, manifest.InternalName)
+			continue
+		}
+
+		scanned[sum] = fmt.Sprintf("%s/%s %s", environment, manifest.InternalName, manifest.AssemblyVersion)
+		clean = append(clean, manifest)
+	}
+
+	if err = writeJSON(cachePath, scanned); err != nil {
+		return nil, err
+	}
+
+	if len(infected) > 0 && cfg.MalwareDetectionAction == "error" {
+		return nil, fmt.Errorf("%s packages are flagged as malicious: %s", environment, strings.Join(infected, ", "))
+	}
+
+	return clean, nil
+}
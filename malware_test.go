@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// rewriteTransport sends every request to a test server, keeping the original path.
+type rewriteTransport struct {
+	target *url.URL
+}
+
+func (t *rewriteTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	request = request.Clone(request.Context())
+	request.URL.Scheme = t.target.Scheme
+	request.URL.Host = t.target.Host
+	return http.DefaultTransport.RoundTrip(request)
+}
+
+func TestVirusTotalUpload(t *testing.T) {
+	var uploadedPath string
+	var uploadedSize int64
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v3/files/upload_url":
+			json.NewEncoder(w).Encode(map[string]string{"data": server.URL + "/large-upload"})
+		case r.Method == http.MethodPost:
+			file, _, err := r.FormFile("file")
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			defer file.Close()
+
+			uploadedPath = r.URL.Path
+			uploadedSize, _ = io.Copy(io.Discard, file)
+			json.NewEncoder(w).Encode(map[string]any{"data": map[string]string{"id": "analysis"}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	target, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := httpClient
+	httpClient = &http.Client{Transport: &rewriteTransport{target: target}}
+	defer func() { httpClient = original }()
+
+	tests := []struct {
+		name string
+		size int64
+		path string
+	}{
+		{name: "small", size: 1024, path: "/api/v3/files"},
+		{name: "limit", size: virusTotalDirectUploadLimit, path: "/api/v3/files"},
+		{name: "large", size: virusTotalDirectUploadLimit + 1, path: "/large-upload"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "latest.zip")
+			file, err := os.Create(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err = file.Truncate(tt.size); err != nil {
+				t.Fatal(err)
+			}
+			file.Close()
+
+			scanner := &VirusTotalScanner{apiKey: "key"}
+			id, err := scanner.upload(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if id != "analysis" || uploadedPath != tt.path || uploadedSize != tt.size {
+				t.Errorf("upload = %q to %s with %d bytes, want analysis to %s with %d bytes", id, uploadedPath, uploadedSize, tt.path, tt.size)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+// Line-based patterns match spaces and tabs rather than \s, which would also consume the blank lines separating
+// paragraphs.
+var (
+	markdownCommentPattern    = regexp.MustCompile(`(?s)<!--.*?-->`)
+	markdownImagePattern      = regexp.MustCompile(`!\[[^\]]*\]\([^)]*\)`)
+	markdownLinkPattern       = regexp.MustCompile(`\[([^\]]*)\]\(([^)\s]*)[^)]*\)`)
+	markdownAutolinkPattern   = regexp.MustCompile(`<(https?://[^>\s]+)>`)
+	markdownTagPattern        = regexp.MustCompile(`</?[A-Za-z][^>]*>`)
+	markdownFencePattern      = regexp.MustCompile("(?m)^[ \\t]*(```|~~~).*$\\n?")
+	markdownHeadingPattern    = regexp.MustCompile(`(?m)^#{1,6}[ \t]+(.*?)[ \t]*#*[ \t]*$`)
+	markdownRulePattern       = regexp.MustCompile(`(?m)^[ \t]*(?:(?:-[ \t]*){3,}|(?:\*[ \t]*){3,}|(?:_[ \t]*){3,})$`)
+	markdownQuotePattern      = regexp.MustCompile(`(?m)^[ \t]*>[ \t]?`)
+	markdownListPattern       = regexp.MustCompile(`(?m)^([ \t]*)[*+][ \t]+`)
+	markdownStrongPattern     = regexp.MustCompile(`(\*\*|__)(\S(?:.*?\S)?)(\*\*|__)`)
+	markdownEmphasisPattern   = regexp.MustCompile(`(^|[^\w*])\*(\S(?:[^*]*?\S)?)\*`)
+	markdownUnderscorePattern = regexp.MustCompile(`(^|\W)_(\S(?:[^_]*?\S)?)_(\W|$)`)
+	markdownStrikePattern     = regexp.MustCompile(`~~(\S(?:.*?\S)?)~~`)
+	markdownCodePattern       = regexp.MustCompile("`([^`]+)`")
+	markdownBlankLinesPattern = regexp.MustCompile(`\n{3,}`)
+)
+
+// MarkdownToPlaintext strips markdown syntax, images and HTML from release notes so that they read well in the
+// plugin installer, which displays changelogs verbatim.
+func MarkdownToPlaintext(markdown string) string {
+	text := strings.ReplaceAll(markdown, "\r\n", "\n")
+
+	text = markdownCommentPattern.ReplaceAllString(text, "")
+	text = markdownFencePattern.ReplaceAllString(text, "")
+	text = markdownImagePattern.ReplaceAllString(text, "")
+	text = markdownLinkPattern.ReplaceAllStringFunc(text, func(link string) string {
+		match := markdownLinkPattern.FindStringSubmatch(link)
+		if match[1] == "" {
+			return match[2]
+		}
+
+		return match[1]
+	})
+	text = markdownAutolinkPattern.ReplaceAllString(text, "$1")
+	text = markdownTagPattern.ReplaceAllString(text, "")
+	text = markdownHeadingPattern.ReplaceAllString(text, "$1")
+	text = markdownRulePattern.ReplaceAllString(text, "")
+	text = markdownQuotePattern.ReplaceAllString(text, "")
+	text = markdownListPattern.ReplaceAllString(text, "$1- ")
+	text = markdownStrongPattern.ReplaceAllString(text, "$2")
+	text = markdownEmphasisPattern.ReplaceAllString(text, "$1$2")
+	text = markdownUnderscorePattern.ReplaceAllString(text, "$1$2$3")
+	text = markdownStrikePattern.ReplaceAllString(text, "$1")
+	text = markdownCodePattern.ReplaceAllString(text, "$1")
+	text = html.UnescapeString(text)
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+
+	return strings.TrimSpace(markdownBlankLinesPattern.ReplaceAllString(strings.Join(lines, "\n"), "\n\n"))
+}
@@ -0,0 +1,49 @@
+package main
+
+import "testing"
+
+func TestMarkdownToPlaintext(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		want     string
+	}{
+		{
+			name:     "headings and lists",
+			markdown: "## What's Changed\n\n* Added a **settings** window\n+ Fixed _the_ crash\n  * nested item\n",
+			want:     "What's Changed\n\n- Added a settings window\n- Fixed the crash\n  - nested item",
+		},
+		{
+			name:     "links and images",
+			markdown: "![screenshot](https://example.com/a.png)See [the docs](https://example.com/docs \"Docs\") or <https://example.com>.\n[](https://example.com/bare)",
+			want:     "See the docs or https://example.com.\nhttps://example.com/bare",
+		},
+		{
+			name:     "code",
+			markdown: "Run `/plugin` to open it.\n\n```csharp\nvar x = 1;\n```",
+			want:     "Run /plugin to open it.\n\nvar x = 1;",
+		},
+		{
+			name:     "html, comments and entities",
+			markdown: "<!-- generated -->\n<details><summary>Details</summary>Fish &amp; chips</details>",
+			want:     "DetailsFish & chips",
+		},
+		{
+			name:     "quotes, rules and strikethrough",
+			markdown: "> Note\n\n---\n\n~~old~~ *new*\n\n\n\nend   ",
+			want:     "Note\n\nold new\n\nend",
+		},
+		{
+			name:     "identifiers keep their underscores and asterisks",
+			markdown: "Renamed snake_case_name and 2*3*4",
+			want:     "Renamed snake_case_name and 2*3*4",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MarkdownToPlaintext(tt.markdown); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"path/filepath"
+	"time"
+)
+
+// PluginMetadata holds human-readable values of a plugin, which stay out of the master to keep the fields Dalamud
+// expects unchanged.
+type PluginMetadata struct {
+	LastUpdate     int64  `json:"LastUpdate,omitempty"`
+	LastUpdateTime string `json:"LastUpdateTime,omitempty"`
+}
+
+// DumpMetadata writes plugins/metadata.json, mapping InternalNames to their LastUpdate as an ISO 8601 timestamp
+// alongside the Unix epoch used in the master.
+func DumpMetadata(manifests []*PluginManifest) error {
+	metadata := map[string]*PluginMetadata{}
+	for _, manifest := range manifests {
+		m := &PluginMetadata{LastUpdate: manifest.LastUpdate}
+		if manifest.LastUpdate > 0 {
+			m.LastUpdateTime = time.Unix(manifest.LastUpdate, 0).UTC().Format(time.RFC3339)
+		}
+
+		metadata[manifest.InternalName] = m
+	}
+
+	return writeJSON(filepath.Join("plugins", "metadata.json"), metadata)
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var metricLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+type metric struct {
+	name  string
+	help  string
+	kind  string
+	lines []string
+}
+
+func (m *metric) add(labels map[string]string, value int64) {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, key, metricLabelEscaper.Replace(labels[key])))
+	}
+
+	m.lines = append(m.lines, fmt.Sprintf("%s{%s} %d", m.name, strings.Join(pairs, ","), value))
+}
+
+// WriteMetrics writes repository statistics in the Prometheus text exposition format.
+func WriteMetrics(w io.Writer, manifests []*PluginManifest) error {
+	downloads := &metric{name: "plugin_download_count", help: "Number of downloads of the plugin.", kind: "gauge"}
+	lastUpdate := &metric{name: "plugin_last_update", help: "Unix time of the last update of the plugin.", kind: "gauge"}
+	count := &metric{name: "plugin_count", help: "Number of plugins listed in the master per channel.", kind: "gauge"}
+
+	channels := map[string]int64{"stable": 0, "testing": 0}
+	for _, manifest := range manifests {
+		if manifest.IsHide {
+			continue
+		}
+
+		labels := map[string]string{"internal_name": manifest.InternalName, "name": manifest.Name}
+		downloads.add(labels, manifest.DownloadCount)
+		lastUpdate.add(labels, manifest.LastUpdate)
+
+		if !manifest.IsTestingExclusive {
+			channels["stable"]++
+		}
+		if manifest.TestingAssemblyVersion != "" {
+			channels["testing"]++
+		}
+	}
+	for _, channel := range []string{"stable", "testing"} {
+		count.add(map[string]string{"channel": channel}, channels[channel])
+	}
+
+	var buffer bytes.Buffer
+	for _, m := range []*metric{downloads, lastUpdate, count} {
+		fmt.Fprintf(&buffer, "# HELP %s %s\n# TYPE %s %s\n", m.name, m.help, m.name, m.kind)
+		for _, line := range m.lines {
+			buffer.WriteString(line + "\n")
+		}
+	}
+
+	_, err := w.Write(buffer.Bytes())
+	return err
+}
+
+// DumpMetrics writes plugins/metrics.prom, which can be scraped over HTTP or read by a textfile collector.
+func DumpMetrics(manifests []*PluginManifest) error {
+	var buffer bytes.Buffer
+	if err := WriteMetrics(&buffer, manifests); err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join("plugins", "metrics.prom"), buffer.Bytes(), 0644)
+}
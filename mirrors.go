@@ -0,0 +1,65 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// rewriteHostingDomain returns a copy of the manifest whose URLs on the hosting domain point to the mirror instead.
+func rewriteHostingDomain(manifest *PluginManifest, domain, mirror string) *PluginManifest {
+	prefix := "https://" + domain + "/"
+	rewrite := func(value string) string {
+		if strings.HasPrefix(value, prefix) {
+			return "https://" + mirror + "/" + strings.TrimPrefix(value, prefix)
+		}
+
+		return value
+	}
+
+	m := *manifest
+	value := reflect.ValueOf(&m).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Field(i)
+		if !field.CanSet() {
+			continue
+		}
+
+		switch {
+		case field.Kind() == reflect.String:
+			field.SetString(rewrite(field.String()))
+		case field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.String && field.Len() > 0:
+			values := reflect.MakeSlice(field.Type(), field.Len(), field.Len())
+			for j := 0; j < field.Len(); j++ {
+				values.Index(j).SetString(rewrite(field.Index(j).String()))
+			}
+			field.Set(values)
+		}
+	}
+
+	return &m
+}
+
+// DumpMirrorMasters writes plugins/mirrors/<mirror>/master.json for each mirror domain, with the download links and
+// other URLs on the hosting domain pointing to the mirror, so that users for whom the primary host is slow can add a
+// mirror's master as their repository instead.
+func DumpMirrorMasters(manifests []*PluginManifest, domain string, mirrors []string) error {
+	directory := filepath.Join("plugins", "mirrors")
+	if err := os.RemoveAll(directory); err != nil {
+		return err
+	}
+
+	for _, mirror := range mirrors {
+		mirrored := make([]*PluginManifest, 0, len(manifests))
+		for _, manifest := range manifests {
+			mirrored = append(mirrored, rewriteHostingDomain(manifest, domain, mirror))
+		}
+
+		if err := writeManifestsJSON(filepath.Join(directory, mirror, "master.json"), mirrored, true); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
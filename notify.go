@@ -0,0 +1,299 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const notificationChangelogLength = 300
+
+// PluginUpdate describes a plugin which is new or whose version changed in a channel since the previous master.
+type PluginUpdate struct {
+	Manifest        *PluginManifest
+	Channel         string
+	Version         string
+	PreviousVersion string
+	Changelog       string
+}
+
+func (u *PluginUpdate) IsNew() bool {
+	return u.PreviousVersion == ""
+}
+
+func (u *PluginUpdate) Title() string {
+	title := fmt.Sprintf("%s %s", u.Manifest.Name, u.Version)
+	if u.IsNew() {
+		return "New plugin: " + title
+	}
+
+	return title
+}
+
+func (u *PluginUpdate) VersionText() string {
+	if u.IsNew() {
+		return u.Version
+	}
+
+	return fmt.Sprintf("%s → %s", u.PreviousVersion, u.Version)
+}
+
+// Color returns the accent color of the update: orange for testing releases, blue for new plugins and green otherwise.
+func (u *PluginUpdate) Color() int {
+	switch {
+	case u.Channel == "testing":
+		return 0xe67e22
+	case u.IsNew():
+		return 0x3498db
+	default:
+		return 0x2ecc71
+	}
+}
+
+// DetectUpdates compares the generated master against the previous one and lists new plugins and version bumps.
+func DetectUpdates(previous, current []*PluginManifest) []*PluginUpdate {
+	previousMap := map[string]*PluginManifest{}
+	for _, manifest := range previous {
+		previousMap[manifest.InternalName] = manifest
+	}
+
+	var updates []*PluginUpdate
+	for _, manifest := range current {
+		if manifest.IsHide {
+			continue
+		}
+
+		old := previousMap[manifest.InternalName]
+		if old == nil {
+			old = &PluginManifest{}
+		}
+
+		if !manifest.IsTestingExclusive && manifest.AssemblyVersion != "" && manifest.AssemblyVersion != old.AssemblyVersion {
+			previousVersion := old.AssemblyVersion
+			if old.IsTestingExclusive {
+				previousVersion = ""
+			}
+
+			updates = append(updates, &PluginUpdate{
+				Manifest:        manifest,
+				Channel:         "stable",
+				Version:         manifest.AssemblyVersion,
+				PreviousVersion: previousVersion,
+				Changelog:       manifest.Changelog,
+			})
+		}
+
+		if manifest.TestingAssemblyVersion != "" && manifest.TestingAssemblyVersion != old.TestingAssemblyVersion {
+			changelog := manifest.TestingChangelog
+			if changelog == "" {
+				changelog = manifest.Changelog
+			}
+
+			updates = append(updates, &PluginUpdate{
+				Manifest:        manifest,
+				Channel:         "testing",
+				Version:         manifest.TestingAssemblyVersion,
+				PreviousVersion: old.TestingAssemblyVersion,
+				Changelog:       changelog,
+			})
+		}
+	}
+
+	return updates
+}
+
+type Notifier interface {
+	Notify(updates []*PluginUpdate) error
+}
+
+func NewNotifiers(cfg *Config) []Notifier {
+	var notifiers []Notifier
+	if cfg.DiscordWebhookURL != "" {
+		notifiers = append(notifiers, &DiscordNotifier{webhookURL: cfg.DiscordWebhookURL})
+	}
+	if cfg.SlackWebhookURL != "" {
+		notifiers = append(notifiers, &SlackNotifier{webhookURL: cfg.SlackWebhookURL})
+	}
+	if cfg.MastodonServer != "" && cfg.MastodonAccessToken != "" {
+		notifiers = append(notifiers, &MastodonNotifier{server: cfg.MastodonServer, accessToken: cfg.MastodonAccessToken})
+	}
+	if cfg.BlueskyIdentifier != "" && cfg.BlueskyPassword != "" {
+		notifiers = append(notifiers, &BlueskyNotifier{
+			service:    strings.TrimSuffix(cfg.BlueskyService, "/"),
+			identifier: cfg.BlueskyIdentifier,
+			password:   cfg.BlueskyPassword,
+		})
+	}
+
+	return notifiers
+}
+
+// postJSON posts the payload to a webhook, waiting and retrying once when the webhook is rate limited.
+func postJSON(url string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	for attempt := 0; ; attempt++ {
+		request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+
+		request.Header.Set("User-Agent", userAgent)
+		request.Header.Set("Content-Type", "application/json")
+
+		response, err := httpClient.Do(request)
+		if err != nil {
+			return err
+		}
+		response.Body.Close()
+
+		if response.StatusCode == http.StatusTooManyRequests && attempt == 0 {
+			seconds, _ := strconv.ParseFloat(response.Header.Get("Retry-After"), 64)
+			time.Sleep(time.Duration(max(seconds, 1) * float64(time.Second)))
+			continue
+		}
+		if response.StatusCode >= 300 {
+			return fmt.Errorf("unexpected webhook status: %s", response.Status)
+		}
+
+		return nil
+	}
+}
+
+type DiscordNotifier struct {
+	webhookURL string
+}
+
+type discordEmbedField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline,omitempty"`
+}
+
+type discordEmbed struct {
+	Title       string               `json:"title"`
+	Description string               `json:"description,omitempty"`
+	URL         string               `json:"url,omitempty"`
+	Color       int                  `json:"color"`
+	Thumbnail   *discordEmbedImage   `json:"thumbnail,omitempty"`
+	Fields      []*discordEmbedField `json:"fields"`
+}
+
+type discordEmbedImage struct {
+	URL string `json:"url"`
+}
+
+// Discord accepts at most 10 embeds per message.
+const maxDiscordEmbeds = 10
+
+func (n *DiscordNotifier) Notify(updates []*PluginUpdate) error {
+	var embeds []*discordEmbed
+	for _, update := range updates {
+		embed := &discordEmbed{
+			Title:       update.Title(),
+			Description: truncateText(update.Changelog, notificationChangelogLength),
+			URL:         update.Manifest.RepoURL,
+			Color:       update.Color(),
+			Fields: []*discordEmbedField{
+				{Name: "Version", Value: update.VersionText(), Inline: true},
+				{Name: "Channel", Value: update.Channel, Inline: true},
+			},
+		}
+		if update.Manifest.IconURL != "" {
+			embed.Thumbnail = &discordEmbedImage{URL: update.Manifest.IconURL}
+		}
+
+		embeds = append(embeds, embed)
+	}
+
+	for len(embeds) > 0 {
+		batch := embeds[:min(len(embeds), maxDiscordEmbeds)]
+		embeds = embeds[len(batch):]
+
+		if err := postJSON(n.webhookURL, map[string]any{"embeds": batch}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SlackNotifier posts to Slack-compatible incoming webhooks, which are also accepted by Mattermost.
+type SlackNotifier struct {
+	webhookURL string
+}
+
+type slackField struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+	Short bool   `json:"short"`
+}
+
+type slackAttachment struct {
+	Fallback  string        `json:"fallback"`
+	Color     string        `json:"color"`
+	Title     string        `json:"title"`
+	TitleLink string        `json:"title_link,omitempty"`
+	Text      string        `json:"text,omitempty"`
+	ThumbURL  string        `json:"thumb_url,omitempty"`
+	Fields    []*slackField `json:"fields"`
+}
+
+func (n *SlackNotifier) Notify(updates []*PluginUpdate) error {
+	var attachments []*slackAttachment
+	for _, update := range updates {
+		attachments = append(attachments, &slackAttachment{
+			Fallback:  fmt.Sprintf("%s (%s)", update.Title(), update.Channel),
+			Color:     fmt.Sprintf("#%06x", update.Color()),
+			Title:     update.Title(),
+			TitleLink: update.Manifest.RepoURL,
+			Text:      truncateText(update.Changelog, notificationChangelogLength),
+			ThumbURL:  update.Manifest.IconURL,
+			Fields: []*slackField{
+				{Title: "Version", Value: update.VersionText(), Short: true},
+				{Title: "Channel", Value: update.Channel, Short: true},
+			},
+		})
+	}
+
+	return postJSON(n.webhookURL, map[string]any{
+		"text":        fmt.Sprintf("%d plugin updates", len(updates)),
+		"attachments": attachments,
+	})
+}
+
+// NotifyUpdates sends the updates since the previous master to every configured notifier. Nothing is sent without a
+// previous master, which would announce every plugin as new.
+func NotifyUpdates(previous, current []*PluginManifest, cfg *Config) error {
+	notifiers := NewNotifiers(cfg)
+	if len(notifiers) == 0 {
+		return nil
+	}
+	if previous == nil {
+		log.Printf("previous master is not found, skipping notifications")
+		return nil
+	}
+
+	updates := DetectUpdates(previous, current)
+	if len(updates) == 0 {
+		return nil
+	}
+
+	var errs []error
+	for _, notifier := range notifiers {
+		if err := notifier.Notify(updates); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
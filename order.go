@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// canonicalFieldOrder is the order of the fields in the official plugin masters, which serialize Dalamud's
+// PluginManifest in declaration order. Fields specific to this repository follow in the order of PluginManifest.
+var canonicalFieldOrder = []string{
+	"Author",
+	"Name",
+	"Punchline",
+	"Description",
+	"Changelog",
+	"Tags",
+	"CategoryTags",
+	"IsHide",
+	"InternalName",
+	"AssemblyVersion",
+	"TestingAssemblyVersion",
+	"IsTestingExclusive",
+	"RepoUrl",
+	"ApplicableVersion",
+	"DalamudApiLevel",
+	"TestingDalamudApiLevel",
+	"DownloadCount",
+	"LastUpdate",
+	"DownloadLinkInstall",
+	"DownloadLinkUpdate",
+	"DownloadLinkTesting",
+	"LoadRequiredState",
+	"LoadSync",
+	"LoadPriority",
+	"CanUnloadAsync",
+	"SupportsProfiles",
+	"ImageUrls",
+	"IconUrl",
+	"AcceptsFeedback",
+	"FeedbackMessage",
+	"Dip17Channel",
+}
+
+type manifestField struct {
+	index     int
+	name      string
+	omitEmpty bool
+}
+
+// manifestFieldOrder lists the JSON fields of PluginManifest in canonical order.
+var manifestFieldOrder = func() []*manifestField {
+	var fields []*manifestField
+	t := reflect.TypeOf(PluginManifest{})
+	for i := 0; i < t.NumField(); i++ {
+		name, options, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if !t.Field(i).IsExported() || name == "-" {
+			continue
+		}
+
+		fields = append(fields, &manifestField{index: i, name: name, omitEmpty: strings.Contains(options, "omitempty")})
+	}
+
+	rank := func(field *manifestField) int {
+		if i := slices.Index(canonicalFieldOrder, field.name); i >= 0 {
+			return i
+		}
+
+		return len(canonicalFieldOrder)
+	}
+	slices.SortStableFunc(fields, func(a, b *manifestField) int {
+		return rank(a) - rank(b)
+	})
+
+	return fields
+}()
+
+// isEmptyValue reports whether encoding/json omits v for omitempty, which also omits empty non-nil slices and maps.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	default:
+		return v.IsZero()
+	}
+}
+
+// canonicalManifest marshals a PluginManifest in canonical field order, which keeps diffs against other repositories
+// and between runs readable. It is a separate type so that structs embedding PluginManifest keep their own fields.
+type canonicalManifest PluginManifest
+
+func (m *canonicalManifest) MarshalJSON() ([]byte, error) {
+	value := reflect.ValueOf(m).Elem()
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for _, field := range manifestFieldOrder {
+		v := value.Field(field.index)
+		if field.omitEmpty && isEmptyValue(v) {
+			continue
+		}
+
+		content, err := json.Marshal(v.Interface())
+		if err != nil {
+			return nil, err
+		}
+
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		name, _ := json.Marshal(field.name)
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(content)
+	}
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
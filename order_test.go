@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestCanonicalManifestOmitsEmptyFields(t *testing.T) {
+	manifest := &PluginManifest{
+		Name:            "Foo",
+		InternalName:    "Foo",
+		AssemblyVersion: "1.0.0.0",
+		Tags:            []string{},
+		CategoryTags:    []string{},
+		ImageURLs:       []string{},
+	}
+
+	got, err := json.Marshal((*canonicalManifest)(manifest))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// encoding/json agrees on which fields are omitted, only the order differs.
+	var canonical, standard map[string]any
+	want, _ := json.Marshal(manifest)
+	if err = json.Unmarshal(got, &canonical); err != nil {
+		t.Fatal(err)
+	}
+	if err = json.Unmarshal(want, &standard); err != nil {
+		t.Fatal(err)
+	}
+	if len(canonical) != len(standard) {
+		t.Errorf("got %s, want the fields of %s", got, want)
+	}
+}
+
+func TestCanonicalManifestFieldOrder(t *testing.T) {
+	// populate every exported field so that none is omitted
+	var manifest PluginManifest
+	value := reflect.ValueOf(&manifest).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Field(i)
+		if !value.Type().Field(i).IsExported() {
+			continue
+		}
+
+		switch field.Kind() {
+		case reflect.String:
+			field.SetString("value")
+		case reflect.Bool:
+			field.SetBool(true)
+		case reflect.Int, reflect.Int64:
+			field.SetInt(1)
+		case reflect.Slice:
+			field.Set(reflect.ValueOf([]string{"value"}))
+		default:
+			t.Fatalf("unhandled field kind: %s", field.Kind())
+		}
+	}
+
+	content, err := json.Marshal((*canonicalManifest)(&manifest))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var keys []string
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	if _, err = decoder.Token(); err != nil {
+		t.Fatal(err)
+	}
+	for decoder.More() {
+		key, err := decoder.Token()
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, key.(string))
+
+		var skipped json.RawMessage
+		if err = decoder.Decode(&skipped); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := append(slices.Clone(canonicalFieldOrder),
+		"Authors",
+		"MinimumDalamudVersion",
+		"TestingChangelog",
+		"Sha256",
+		"TestingSha256",
+		"DeltaBaseVersion",
+		"DownloadLinkDelta",
+		"TestingDeltaBaseVersion",
+		"DownloadLinkTestingDelta",
+		"DownloadLinkSignature",
+		"DownloadLinkTestingSignature",
+	)
+	if !slices.Equal(keys, want) {
+		t.Errorf("keys = %v, want %v", keys, want)
+	}
+}
+
+func TestWriteManifestsJSON(t *testing.T) {
+	manifests := []*PluginManifest{
+		{Name: "Foo", InternalName: "Foo", AssemblyVersion: "1.0.0.0", Tags: []string{"a", "b"}, DalamudApiLevel: 9},
+		{Name: "Bar", InternalName: "Bar", AssemblyVersion: "0.1.0.0", Description: "<b>\"quoted\"</b>"},
+	}
+
+	for _, tt := range []struct {
+		name      string
+		manifests []*PluginManifest
+	}{
+		{name: "manifests", manifests: manifests},
+		{name: "empty", manifests: []*PluginManifest{}},
+	} {
+		for _, indent := range []bool{true, false} {
+			path := filepath.Join(t.TempDir(), "master.json")
+			if err := writeManifestsJSON(path, tt.manifests, indent); err != nil {
+				t.Fatal(err)
+			}
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			converted := make([]*canonicalManifest, len(tt.manifests))
+			for i, manifest := range tt.manifests {
+				converted[i] = (*canonicalManifest)(manifest)
+			}
+			var want []byte
+			if indent {
+				want, err = json.MarshalIndent(converted, "", "  ")
+			} else {
+				want, err = json.Marshal(converted)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(got, want) {
+				t.Errorf("%s (indent %v): got %s, want %s", tt.name, indent, got, want)
+			}
+		}
+	}
+}
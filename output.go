@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+func writeJSON(path string, v any) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	content, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, content, 0644)
+}
+
+// writeManifestsJSON streams a master to path one manifest at a time in canonical field order, so that the encoded
+// master is not buffered as a whole next to the manifests. The output is identical to json.MarshalIndent with an indent of two
+// spaces, or to json.Marshal when indent is false.
+func writeManifestsJSON(path string, manifests []*PluginManifest, indent bool) (err error) {
+	if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	separator, prefix, suffix := ",", "[", "]"
+	if indent {
+		separator, prefix, suffix = ",\n  ", "[\n  ", "\n]"
+	}
+
+	w := bufio.NewWriter(f)
+	if len(manifests) == 0 {
+		prefix, suffix = "[", "]"
+	}
+	if _, err = w.WriteString(prefix); err != nil {
+		return err
+	}
+
+	for i, manifest := range manifests {
+		var content []byte
+		if indent {
+			content, err = json.MarshalIndent((*canonicalManifest)(manifest), "  ", "  ")
+		} else {
+			content, err = json.Marshal((*canonicalManifest)(manifest))
+		}
+		if err != nil {
+			return err
+		}
+
+		if i > 0 {
+			if _, err = w.WriteString(separator); err != nil {
+				return err
+			}
+		}
+		if _, err = w.Write(content); err != nil {
+			return err
+		}
+	}
+
+	if _, err = w.WriteString(suffix); err != nil {
+		return err
+	}
+
+	return w.Flush()
+}
+
+// DumpPluginEndpoints writes plugins/api/<InternalName>.json for each plugin, removing endpoints of plugins
+// which are no longer in the master.
+func DumpPluginEndpoints(manifests []*PluginManifest) error {
+	directory := filepath.Join("plugins", "api")
+
+	stale, err := filepath.Glob(filepath.Join(directory, "*.json"))
+	if err != nil {
+		return err
+	}
+	for _, path := range stale {
+		if err = os.Remove(path); err != nil {
+			return err
+		}
+	}
+
+	for _, manifest := range manifests {
+		endpoint := struct {
+			*PluginManifest
+			ChannelDownloads *ChannelDownloads `json:"ChannelDownloadCount,omitempty"`
+		}{manifest, manifest.channelDownloads}
+
+		if err = writeJSON(filepath.Join(directory, manifest.InternalName+".json"), endpoint); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+func readOptionalJSON(path string, v any) error {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(content, v)
+}
+
+type RetiredPlugin struct {
+	InternalName string `json:"InternalName"`
+	Message      string `json:"Message,omitempty"`
+}
+
+func LoadRetiredPlugins() ([]*RetiredPlugin, error) {
+	var retired []*RetiredPlugin
+	if err := readOptionalJSON(filepath.Join("plugins", "retired.json"), &retired); err != nil {
+		return nil, err
+	}
+
+	return retired, nil
+}
+
+func findManifest(manifests []*PluginManifest, name string) *PluginManifest {
+	for _, manifest := range manifests {
+		if manifest.InternalName == name {
+			return manifest
+		}
+	}
+
+	return nil
+}
+
+func RetireManifests(manifests []*PluginManifest, retired []*RetiredPlugin, previous []*PluginManifest) []*PluginManifest {
+	for _, plugin := range retired {
+		manifest := findManifest(manifests, plugin.InternalName)
+		if manifest == nil {
+			p := findManifest(previous, plugin.InternalName)
+			if p == nil {
+				log.Printf("%s: retired plugin is not found in the previous master, skipping", plugin.InternalName)
+				continue
+			}
+
+			m := *p
+			manifest = &m
+			manifests = append(manifests, manifest)
+		}
+
+		manifest.IsHide = true
+		manifest.DownloadLinkInstall = ""
+		manifest.DownloadLinkUpdate = ""
+		manifest.DownloadLinkTesting = ""
+		manifest.DownloadLinkDelta = ""
+		manifest.DownloadLinkTestingDelta = ""
+		manifest.DeltaBaseVersion = ""
+		manifest.TestingDeltaBaseVersion = ""
+		manifest.DownloadLinkSignature = ""
+		manifest.DownloadLinkTestingSignature = ""
+		manifest.Sha256 = ""
+		manifest.TestingSha256 = ""
+		if plugin.Message != "" {
+			manifest.Punchline = plugin.Message
+		}
+	}
+
+	return manifests
+}
+
+func LoadRenames() (map[string]string, error) {
+	renames := map[string]string{}
+	if err := readOptionalJSON(filepath.Join("plugins", "renames.json"), &renames); err != nil {
+		return nil, err
+	}
+
+	return renames, nil
+}
+
+func AddRenameRedirects(manifests []*PluginManifest, renames map[string]string) []*PluginManifest {
+	var oldNames []string
+	for oldName := range renames {
+		oldNames = append(oldNames, oldName)
+	}
+	sort.Strings(oldNames)
+
+	for _, oldName := range oldNames {
+		newName := renames[oldName]
+		if findManifest(manifests, oldName) != nil {
+			log.Printf("%s: renamed plugin still exists, skipping redirect to %s", oldName, newName)
+			continue
+		}
+
+		target := findManifest(manifests, newName)
+		if target == nil {
+			log.Printf("%s: rename target %s is not found, skipping", oldName, newName)
+			continue
+		}
+
+		redirect := *target
+		redirect.InternalName = oldName
+		redirect.IsHide = true
+		manifests = append(manifests, &redirect)
+	}
+
+	return manifests
+}
+
+type BannedPlugin struct {
+	InternalName string   `json:"InternalName"`
+	Reason       string   `json:"Reason,omitempty"`
+	Versions     []string `json:"Versions,omitempty"`
+}
+
+func LoadDenylist() ([]*BannedPlugin, error) {
+	var denylist []*BannedPlugin
+	if err := readOptionalJSON(filepath.Join("plugins", "denylist.json"), &denylist); err != nil {
+		return nil, err
+	}
+
+	return denylist, nil
+}
+
+func IsBanned(denylist []*BannedPlugin, name, version string) (*BannedPlugin, bool) {
+	for _, banned := range denylist {
+		if banned.InternalName != name {
+			continue
+		}
+
+		if len(banned.Versions) == 0 || slices.Contains(banned.Versions, version) {
+			return banned, true
+		}
+	}
+
+	return nil, false
+}
+
+func FilterBannedManifests(manifests []*PluginManifest, denylist []*BannedPlugin, environment string) []*PluginManifest {
+	var filtered []*PluginManifest
+	for _, manifest := range manifests {
+		if banned, ok := IsBanned(denylist, manifest.InternalName, manifest.AssemblyVersion); ok {
+			log.Printf("%s: %s %s is banned: %s", manifest.InternalName, environment, manifest.AssemblyVersion, banned.Reason)
+			continue
+		}
+
+		filtered = append(filtered, manifest)
+	}
+
+	return filtered
+}
+
+func LoadHiddenPlugins() ([]string, error) {
+	var hidden []string
+	if err := readOptionalJSON(filepath.Join("plugins", "hidden.json"), &hidden); err != nil {
+		return nil, err
+	}
+
+	return hidden, nil
+}
+
+func LoadAuthorAliases() (map[string]string, error) {
+	aliases := map[string]string{}
+	if err := readOptionalJSON(filepath.Join("plugins", "authors.json"), &aliases); err != nil {
+		return nil, err
+	}
+
+	return aliases, nil
+}
+
+// LoadDownloadAliases reads plugins/download-aliases.json, which maps keys of the download statistics to the
+// InternalName they should be counted for.
+func LoadDownloadAliases() (map[string]string, error) {
+	aliases := map[string]string{}
+	if err := readOptionalJSON(filepath.Join("plugins", "download-aliases.json"), &aliases); err != nil {
+		return nil, err
+	}
+
+	return aliases, nil
+}
+
+func NormalizeAuthors(manifest *PluginManifest, aliases map[string]string) {
+	authors := manifest.Authors
+	if len(authors) == 0 && manifest.Author != "" {
+		authors = strings.Split(manifest.Author, ",")
+	}
+
+	var normalized []string
+	for _, author := range authors {
+		author = strings.TrimSpace(author)
+		if alias, ok := aliases[author]; ok {
+			author = alias
+		}
+
+		if author == "" || slices.Contains(normalized, author) {
+			continue
+		}
+
+		normalized = append(normalized, author)
+	}
+
+	manifest.Author = strings.Join(normalized, ", ")
+	manifest.Authors = nil
+}
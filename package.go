@@ -0,0 +1,261 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+func InspectPackage(archive *zip.Reader, manifest *PluginManifest, cfg *Config) []string {
+	// size limits are checked before anything is decompressed
+	if problems := checkZipSizes(archive, cfg); len(problems) > 0 {
+		return problems
+	}
+
+	if problems := checkZipContents(archive, cfg.AllowedZipExtensions); len(problems) > 0 {
+		return problems
+	}
+
+	if cfg.VerifyZipIntegrity {
+		if err := VerifyZipIntegrity(archive); err != nil {
+			return []string{fmt.Sprintf("latest.zip is corrupted: %v", err)}
+		}
+	}
+
+	var problems []string
+
+	dll := findZipFile(archive, manifest.InternalName+".dll")
+	if dll == nil {
+		problems = append(problems, fmt.Sprintf("%s.dll is not found in latest.zip", manifest.InternalName))
+	} else if cfg.CheckAssemblyVersion || cfg.DalamudApiLevelDetection != "off" {
+		metadata, err := readAssemblyMetadataFromZip(dll)
+		if err != nil {
+			problems = append(problems, err.Error())
+		} else {
+			if cfg.CheckAssemblyVersion {
+				problems = append(problems, checkAssemblyVersion(manifest, metadata)...)
+			}
+
+			problems = append(problems, checkDalamudApiLevel(archive, manifest, metadata, cfg.DalamudApiLevelDetection)...)
+		}
+	}
+
+	if cfg.RequireEmbeddedManifest && findZipFile(archive, manifest.InternalName+".json") == nil {
+		problems = append(problems, fmt.Sprintf("%s.json is not found in latest.zip", manifest.InternalName))
+	}
+
+	return problems
+}
+
+func checkZipSizes(archive *zip.Reader, cfg *Config) []string {
+	var problems []string
+
+	var compressed, uncompressed uint64
+	for _, file := range archive.File {
+		compressed += file.CompressedSize64
+		uncompressed += file.UncompressedSize64
+
+		if cfg.MaxZipEntrySize > 0 && file.UncompressedSize64 > uint64(cfg.MaxZipEntrySize) {
+			problems = append(problems, fmt.Sprintf("%s exceeds the entry size limit: %d > %d bytes", file.Name, file.UncompressedSize64, cfg.MaxZipEntrySize))
+		}
+	}
+
+	if cfg.MaxZipCompressedSize > 0 && compressed > uint64(cfg.MaxZipCompressedSize) {
+		problems = append(problems, fmt.Sprintf("latest.zip exceeds the compressed size limit: %d > %d bytes", compressed, cfg.MaxZipCompressedSize))
+	}
+	if cfg.MaxZipUncompressedSize > 0 && uncompressed > uint64(cfg.MaxZipUncompressedSize) {
+		problems = append(problems, fmt.Sprintf("latest.zip exceeds the uncompressed size limit: %d > %d bytes", uncompressed, cfg.MaxZipUncompressedSize))
+	}
+
+	return problems
+}
+
+// NormalizeZipExtensions lower-cases ALLOWED_ZIP_EXTENSIONS and strips surrounding spaces and a leading dot, so
+// that " .DLL" allows the same files as "dll".
+func NormalizeZipExtensions(extensions []string) []string {
+	var normalized []string
+	for _, extension := range extensions {
+		extension = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(extension)), ".")
+		if extension != "" {
+			normalized = append(normalized, extension)
+		}
+	}
+
+	return normalized
+}
+
+// checkZipContents rejects files whose extension is not allowed. Files without an extension, such as LICENSE or
+// README, are always allowed since Windows neither executes nor loads them.
+func checkZipContents(archive *zip.Reader, allowed []string) []string {
+	if len(allowed) == 0 {
+		return nil
+	}
+
+	var problems []string
+	for _, file := range archive.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+
+		extension := strings.TrimPrefix(strings.ToLower(path.Ext(file.Name)), ".")
+		if extension != "" && !slices.Contains(allowed, extension) {
+			problems = append(problems, fmt.Sprintf("%s has a disallowed file type", file.Name))
+		}
+	}
+
+	return problems
+}
+
+// VerifyZipIntegrity reads every entry to the end so that archive/zip validates its CRC-32 checksum.
+func VerifyZipIntegrity(archive *zip.Reader) error {
+	for _, file := range archive.File {
+		reader, err := file.Open()
+		if err != nil {
+			return fmt.Errorf("%s: %w", file.Name, err)
+		}
+
+		_, err = io.Copy(io.Discard, reader)
+		reader.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", file.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func readAssemblyMetadataFromZip(dll *zip.File) (*AssemblyMetadata, error) {
+	content, err := readZipFile(dll)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", dll.Name, err)
+	}
+
+	metadata, err := ReadAssemblyMetadata(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read assembly metadata of %s: %w", dll.Name, err)
+	}
+
+	return metadata, nil
+}
+
+func checkAssemblyVersion(manifest *PluginManifest, metadata *AssemblyMetadata) []string {
+	if version := metadata.Version.String(); NormalizeVersion(manifest.AssemblyVersion) != version {
+		return []string{fmt.Sprintf("AssemblyVersion %q does not match %s.dll version %s", manifest.AssemblyVersion, metadata.Name, version)}
+	}
+
+	return nil
+}
+
+// DetectDalamudApiLevel derives the API level from the referenced Dalamud assembly, whose major version tracks
+// the API level, falling back to the manifest embedded in the zip.
+func DetectDalamudApiLevel(archive *zip.Reader, manifest *PluginManifest, metadata *AssemblyMetadata) int {
+	if reference, ok := metadata.FindReference("Dalamud"); ok && reference.Version.Major > 0 {
+		return int(reference.Version.Major)
+	}
+
+	file := findZipFile(archive, manifest.InternalName+".json")
+	if file == nil {
+		return 0
+	}
+
+	content, err := readZipFile(file)
+	if err != nil {
+		return 0
+	}
+
+	var embedded PluginManifest
+	if err = json.Unmarshal(content, &embedded); err != nil {
+		return 0
+	}
+
+	return embedded.DalamudApiLevel
+}
+
+func checkDalamudApiLevel(archive *zip.Reader, manifest *PluginManifest, metadata *AssemblyMetadata, mode string) []string {
+	if mode == "off" {
+		return nil
+	}
+
+	detected := DetectDalamudApiLevel(archive, manifest, metadata)
+	if detected == 0 || detected == manifest.DalamudApiLevel {
+		return nil
+	}
+
+	if mode == "populate" {
+		log.Printf("%s: DalamudApiLevel is set to %d detected from latest.zip (declared %d)", manifest.InternalName, detected, manifest.DalamudApiLevel)
+		manifest.DalamudApiLevel = detected
+		return nil
+	}
+
+	return []string{fmt.Sprintf("DalamudApiLevel %d does not match %d detected from latest.zip", manifest.DalamudApiLevel, detected)}
+}
+
+// VerifyPackages inspects the latest.zip of every plugin. Broken packages are only logged and still published by
+// default ("warn"), so that removing plugins from the master is opt-in: "skip" leaves them out and "error" fails the
+// run.
+func VerifyPackages(manifests []*PluginManifest, environment string, cfg *Config) ([]*PluginManifest, error) {
+	switch cfg.PackageValidation {
+	case "off":
+		return manifests, nil
+	case "warn", "skip", "error":
+	default:
+		return nil, fmt.Errorf("unknown package validation mode: %s", cfg.PackageValidation)
+	}
+
+	switch cfg.DalamudApiLevelDetection {
+	case "off", "check", "populate":
+	default:
+		return nil, fmt.Errorf("unknown DalamudApiLevel detection mode: %s", cfg.DalamudApiLevelDetection)
+	}
+
+	level := "warning"
+	if cfg.PackageValidation == "error" {
+		level = "error"
+	}
+
+	var verified []*PluginManifest
+	var broken []string
+	for _, manifest := range manifests {
+		directory := filepath.Join("plugins", environment, manifest.InternalName)
+
+		var problems []string
+		archive, err := OpenPluginZip(directory)
+		switch {
+		case err != nil:
+			problems = []string{fmt.Sprintf("failed to open latest.zip: %v", err)}
+		case archive == nil:
+			problems = []string{"latest.zip is not found"}
+		default:
+			problems = InspectPackage(&archive.Reader, manifest, cfg)
+			archive.Close()
+		}
+
+		if len(problems) == 0 {
+			verified = append(verified, manifest)
+			continue
+		}
+
+		for _, problem := range problems {
+			message := fmt.Sprintf("%s package: %s", environment, problem)
+			log.Printf("%s: %s", manifest.InternalName, message)
+			AnnotateManifest(level, manifest.InternalName, message)
+		}
+		broken = append(broken, manifest.InternalName)
+
+		if cfg.PackageValidation == "warn" {
+			verified = append(verified, manifest)
+		}
+	}
+
+	if cfg.PackageValidation == "error" && len(broken) > 0 {
+		return nil, fmt.Errorf("%s packages are broken: %s", environment, strings.Join(broken, ", "))
+	}
+
+	return verified, nil
+}
@@ -0,0 +1,314 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/caarlos0/env/v10"
+)
+
+// defaultConfig returns the configuration with every option at its default.
+func defaultConfig(t *testing.T) *Config {
+	t.Helper()
+
+	var cfg Config
+	if err := env.ParseWithOptions(&cfg, env.Options{Environment: map[string]string{}}); err != nil {
+		t.Fatal(err)
+	}
+
+	return &cfg
+}
+
+// chdir changes the working directory for the duration of the test, since the generator works on the plugins
+// directory relative to it.
+func chdir(t *testing.T, directory string) {
+	t.Helper()
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Chdir(directory); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := os.Chdir(wd); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+type zipEntry struct {
+	name    string
+	content []byte
+	// badCRC stores the entry with a wrong CRC-32, as a corrupted upload would.
+	badCRC bool
+}
+
+func buildZip(t *testing.T, entries []zipEntry) []byte {
+	t.Helper()
+
+	var buffer bytes.Buffer
+	writer := zip.NewWriter(&buffer)
+	for _, entry := range entries {
+		if !entry.badCRC {
+			w, err := writer.Create(entry.name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err = w.Write(entry.content); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+
+		w, err := writer.CreateRaw(&zip.FileHeader{
+			Name:               entry.name,
+			Method:             zip.Store,
+			CRC32:              crc32.ChecksumIEEE(entry.content) + 1,
+			CompressedSize64:   uint64(len(entry.content)),
+			UncompressedSize64: uint64(len(entry.content)),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = w.Write(entry.content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buffer.Bytes()
+}
+
+func openZip(t *testing.T, content []byte) *zip.Reader {
+	t.Helper()
+
+	archive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return archive
+}
+
+func testAssembly(t *testing.T) []byte {
+	t.Helper()
+
+	// testdata/lib1.dll is an empty net8.0 class library built with <AssemblyVersion>1.2.3.4</AssemblyVersion>.
+	content, err := os.ReadFile(filepath.Join("testdata", "lib1.dll"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return content
+}
+
+func TestInspectPackage(t *testing.T) {
+	dll := testAssembly(t)
+	manifest := func() *PluginManifest {
+		return &PluginManifest{InternalName: "lib1", AssemblyVersion: "1.2.3.4", DalamudApiLevel: 9}
+	}
+
+	tests := []struct {
+		name      string
+		entries   []zipEntry
+		configure func(cfg *Config)
+		manifest  func(m *PluginManifest)
+		want      string
+	}{
+		{
+			name:    "valid",
+			entries: []zipEntry{{name: "lib1.dll", content: dll}, {name: "lib1.json", content: []byte(`{"DalamudApiLevel": 9}`)}},
+		},
+		{
+			name:      "entry size limit",
+			entries:   []zipEntry{{name: "lib1.dll", content: dll}},
+			configure: func(cfg *Config) { cfg.MaxZipEntrySize = 1024 },
+			want:      "exceeds the entry size limit",
+		},
+		{
+			name:      "compressed size limit",
+			entries:   []zipEntry{{name: "lib1.dll", content: dll}},
+			configure: func(cfg *Config) { cfg.MaxZipCompressedSize = 16 },
+			want:      "exceeds the compressed size limit",
+		},
+		{
+			name:      "uncompressed size limit",
+			entries:   []zipEntry{{name: "lib1.dll", content: dll}},
+			configure: func(cfg *Config) { cfg.MaxZipUncompressedSize = 1024 },
+			want:      "exceeds the uncompressed size limit",
+		},
+		{
+			name:    "nested archive",
+			entries: []zipEntry{{name: "lib1.dll", content: dll}, {name: "bundle.zip", content: []byte("PK")}},
+			want:    "bundle.zip has a disallowed file type",
+		},
+		{
+			name:    "disallowed file type",
+			entries: []zipEntry{{name: "lib1.dll", content: dll}, {name: "install.ps1", content: []byte("echo")}},
+			want:    "install.ps1 has a disallowed file type",
+		},
+		{
+			name:    "corrupted entry",
+			entries: []zipEntry{{name: "lib1.dll", content: dll, badCRC: true}},
+			want:    "latest.zip is corrupted",
+		},
+		{
+			name:    "missing assembly",
+			entries: []zipEntry{{name: "other.dll", content: dll}},
+			want:    "lib1.dll is not found in latest.zip",
+		},
+		{
+			name:    "unreadable assembly",
+			entries: []zipEntry{{name: "lib1.dll", content: []byte("not an assembly")}},
+			want:    "failed to read assembly metadata of lib1.dll",
+		},
+		{
+			name:     "assembly version mismatch",
+			entries:  []zipEntry{{name: "lib1.dll", content: dll}},
+			manifest: func(m *PluginManifest) { m.AssemblyVersion = "1.0.0.0" },
+			want:     `AssemblyVersion "1.0.0.0" does not match lib1.dll version 1.2.3.4`,
+		},
+		{
+			name:    "DalamudApiLevel mismatch",
+			entries: []zipEntry{{name: "lib1.dll", content: dll}, {name: "lib1.json", content: []byte(`{"DalamudApiLevel": 10}`)}},
+			want:    "DalamudApiLevel 9 does not match 10 detected from latest.zip",
+		},
+		{
+			name:      "missing embedded manifest",
+			entries:   []zipEntry{{name: "lib1.dll", content: dll}},
+			configure: func(cfg *Config) { cfg.RequireEmbeddedManifest = true },
+			want:      "lib1.json is not found in latest.zip",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := defaultConfig(t)
+			if tt.configure != nil {
+				tt.configure(cfg)
+			}
+			m := manifest()
+			if tt.manifest != nil {
+				tt.manifest(m)
+			}
+
+			problems := InspectPackage(openZip(t, buildZip(t, tt.entries)), m, cfg)
+			if tt.want == "" {
+				if len(problems) > 0 {
+					t.Errorf("unexpected problems: %q", problems)
+				}
+				return
+			}
+			if !slices.ContainsFunc(problems, func(problem string) bool { return strings.Contains(problem, tt.want) }) {
+				t.Errorf("got %q, want a problem containing %q", problems, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyPackages(t *testing.T) {
+	dll := testAssembly(t)
+
+	root := t.TempDir()
+	for name, entries := range map[string][]zipEntry{
+		"Good":   {{name: "Good.dll", content: dll}},
+		"Broken": {{name: "Other.dll", content: dll}},
+	} {
+		directory := filepath.Join(root, "plugins", "stable", name)
+		if err := os.MkdirAll(directory, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(directory, "latest.zip"), buildZip(t, entries), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	chdir(t, root)
+
+	manifests := []*PluginManifest{
+		{InternalName: "Good", AssemblyVersion: "1.2.3.4"},
+		{InternalName: "Broken", AssemblyVersion: "1.2.3.4"},
+		{InternalName: "Missing", AssemblyVersion: "1.2.3.4"},
+	}
+
+	tests := []struct {
+		mode    string
+		want    []string
+		wantErr bool
+	}{
+		{mode: "off", want: []string{"Good", "Broken", "Missing"}},
+		{mode: "warn", want: []string{"Good", "Broken", "Missing"}},
+		{mode: "skip", want: []string{"Good"}},
+		{mode: "error", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			cfg := defaultConfig(t)
+			cfg.PackageValidation = tt.mode
+
+			verified, err := VerifyPackages(manifests, "stable", cfg)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "Broken, Missing") {
+					t.Errorf("got %v, want an error naming Broken and Missing", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var names []string
+			for _, manifest := range verified {
+				names = append(names, manifest.InternalName)
+			}
+			if !slices.Equal(names, tt.want) {
+				t.Errorf("got %v, want %v", names, tt.want)
+			}
+		})
+	}
+
+	if cfg := defaultConfig(t); cfg.PackageValidation != "warn" {
+		t.Errorf("PACKAGE_VALIDATION defaults to %q, broken packages must only be dropped on request", cfg.PackageValidation)
+	}
+}
+
+func TestNormalizeZipExtensions(t *testing.T) {
+	got := NormalizeZipExtensions([]string{"dll", " .DLL", "Json ", ".", "", " png"})
+	if want := []string{"dll", "dll", "json", "png"}; !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestCheckZipContents(t *testing.T) {
+	allowed := NormalizeZipExtensions([]string{".DLL", " Json"})
+
+	tests := []struct {
+		name string
+		file string
+		ok   bool
+	}{
+		{name: "allowed", file: "Plugin.dll", ok: true},
+		{name: "allowed with upper case", file: "Plugin.JSON", ok: true},
+		{name: "nested directory", file: "lib/Dependency.dll", ok: true},
+		{name: "extensionless", file: "LICENSE", ok: true},
+		{name: "trailing dot", file: "README.", ok: true},
+		{name: "disallowed", file: "setup.exe", ok: false},
+		{name: "disguised", file: "Plugin.dll.exe", ok: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			archive := openZip(t, buildZip(t, []zipEntry{{name: tt.file, content: []byte("x")}}))
+			if problems := checkZipContents(archive, allowed); (len(problems) == 0) != tt.ok {
+				t.Errorf("checkZipContents(%q) = %q", tt.file, problems)
+			}
+		})
+	}
+}
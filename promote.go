@@ -0,0 +1,219 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// channelFiles are copied along with the manifest when a plugin moves between channels.
+var channelFiles = []string{"latest.zip", "commits.json", "event.json"}
+
+func findManifestFile(directory, name string) (string, *PluginManifest, error) {
+	entries, err := os.ReadDir(directory)
+	if os.IsNotExist(err) {
+		return "", nil, nil
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") || slices.Contains(reservedFilenames, entry.Name()) {
+			continue
+		}
+
+		path := filepath.Join(directory, entry.Name())
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return "", nil, err
+		}
+
+		var manifest PluginManifest
+		if err = json.Unmarshal(content, &manifest); err != nil {
+			return "", nil, fmt.Errorf("%s: %w", path, err)
+		}
+
+		if manifest.InternalName == name {
+			return path, &manifest, nil
+		}
+	}
+
+	return "", nil, nil
+}
+
+// ArchiveSnapshot keeps the current zip and manifest of a channel as <version>.zip and <version>.manifest so that
+// they can be restored later.
+func ArchiveSnapshot(directory, manifestPath, version string) error {
+	if err := ArchiveRelease(directory, version); err != nil {
+		return err
+	}
+
+	return copyFile(manifestPath, filepath.Join(directory, version+".manifest"))
+}
+
+func PromotePlugin(name string, force bool) error {
+	testingDir := filepath.Join("plugins", "testing", name)
+	stableDir := filepath.Join("plugins", "stable", name)
+
+	testingPath, testingManifest, err := findManifestFile(testingDir, name)
+	if err != nil {
+		return err
+	}
+	if testingManifest == nil {
+		return fmt.Errorf("%s is not found in the testing channel", name)
+	}
+	if _, err = os.Stat(filepath.Join(testingDir, "latest.zip")); err != nil {
+		return fmt.Errorf("testing latest.zip is not available: %w", err)
+	}
+	if testingManifest.DalamudApiLevel <= 0 {
+		return fmt.Errorf("testing DalamudApiLevel is invalid: %d", testingManifest.DalamudApiLevel)
+	}
+
+	stablePath, stableManifest, err := findManifestFile(stableDir, name)
+	if err != nil {
+		return err
+	}
+
+	if stableManifest != nil {
+		if CompareVersions(testingManifest.AssemblyVersion, stableManifest.AssemblyVersion) <= 0 && !force {
+			return fmt.Errorf("testing version %s is not newer than stable version %s", testingManifest.AssemblyVersion, stableManifest.AssemblyVersion)
+		}
+		if testingManifest.DalamudApiLevel < stableManifest.DalamudApiLevel && !force {
+			return fmt.Errorf("testing DalamudApiLevel %d is lower than stable %d", testingManifest.DalamudApiLevel, stableManifest.DalamudApiLevel)
+		}
+
+		if err = ArchiveSnapshot(stableDir, stablePath, stableManifest.AssemblyVersion); err != nil {
+			return fmt.Errorf("failed to archive the current stable release: %w", err)
+		}
+		if err = os.Remove(stablePath); err != nil {
+			return err
+		}
+	}
+
+	if err = os.MkdirAll(stableDir, 0755); err != nil {
+		return err
+	}
+
+	if err = copyFile(testingPath, filepath.Join(stableDir, filepath.Base(testingPath))); err != nil {
+		return err
+	}
+	for _, filename := range channelFiles {
+		src, dst := filepath.Join(testingDir, filename), filepath.Join(stableDir, filename)
+		if _, err = os.Stat(src); os.IsNotExist(err) {
+			// drop stale files such as commits.json of the previous stable release
+			if err = os.Remove(dst); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			continue
+		}
+
+		if err = copyFile(src, dst); err != nil {
+			return err
+		}
+	}
+
+	log.Printf("%s: promoted %s to stable", name, testingManifest.AssemblyVersion)
+	return nil
+}
+
+func promoteCommand(cfg *Config, args []string) error {
+	flags := flag.NewFlagSet("promote", flag.ContinueOnError)
+	force := flags.Bool("force", false, "skip version and API level sanity checks")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("usage: promote [-force] <InternalName>")
+	}
+
+	if err := PromotePlugin(flags.Arg(0), *force); err != nil {
+		return err
+	}
+
+	generate(cfg)
+	return nil
+}
+
+func RollbackPlugin(environment, name, version string) error {
+	directory := filepath.Join("plugins", environment, name)
+
+	manifestPath, manifest, err := findManifestFile(directory, name)
+	if err != nil {
+		return err
+	}
+	if manifest == nil {
+		return fmt.Errorf("%s is not found in the %s channel", name, environment)
+	}
+
+	if version == "" {
+		versions, err := ListReleases(directory)
+		if err != nil {
+			return err
+		}
+
+		// zips archived by ARCHIVE_RELEASES have no manifest and cannot be restored
+		for _, v := range versions {
+			if _, err = os.Stat(filepath.Join(directory, v+".manifest")); err != nil {
+				continue
+			}
+			if CompareVersions(v, manifest.AssemblyVersion) < 0 {
+				version = v
+				break
+			}
+		}
+		if version == "" {
+			return fmt.Errorf("no archived release older than %s is found", manifest.AssemblyVersion)
+		}
+	}
+
+	archivedZip := filepath.Join(directory, version+".zip")
+	archivedManifest := filepath.Join(directory, version+".manifest")
+	for _, path := range []string{archivedZip, archivedManifest} {
+		if _, err = os.Stat(path); err != nil {
+			return fmt.Errorf("archived release %s is not available: %w", version, err)
+		}
+	}
+
+	if err = ArchiveSnapshot(directory, manifestPath, manifest.AssemblyVersion); err != nil {
+		return fmt.Errorf("failed to archive the current release: %w", err)
+	}
+
+	if err = copyFile(archivedZip, filepath.Join(directory, "latest.zip")); err != nil {
+		return err
+	}
+	if err = copyFile(archivedManifest, manifestPath); err != nil {
+		return err
+	}
+
+	log.Printf("%s: rolled back %s from %s to %s", name, environment, manifest.AssemblyVersion, version)
+	return nil
+}
+
+func rollbackCommand(cfg *Config, args []string) error {
+	flags := flag.NewFlagSet("rollback", flag.ContinueOnError)
+	environment := flags.String("channel", "stable", "channel to roll back (stable or testing)")
+	version := flags.String("version", "", "archived version to restore (defaults to the newest older release)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("usage: rollback [-channel stable|testing] [-version <version>] <InternalName>")
+	}
+	if *environment != "stable" && *environment != "testing" {
+		return fmt.Errorf("unknown channel: %s", *environment)
+	}
+
+	if err := RollbackPlugin(*environment, flags.Arg(0), *version); err != nil {
+		return err
+	}
+
+	generate(cfg)
+	return nil
+}
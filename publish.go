@@ -0,0 +1,132 @@
+package main
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"io/fs"
+	"log"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// internalStateFiles are kept in the plugins directory between runs but are not meant to be served.
+var internalStateFiles = []string{"plugins/checksums.json", "plugins/scanned.json", "plugins/stats/cloudflare.json"}
+
+// isMasterFile reports whether the file is a master or one of its sidecars, which reference the zips and other files
+// of the tree and are therefore uploaded last.
+func isMasterFile(p string) bool {
+	p = filepath.ToSlash(p)
+	return strings.HasPrefix(path.Base(p), "master.") || p == "plugins/stable.json" || p == "plugins/testing.json"
+}
+
+// PublishTree syncs the plugins directory and robots.txt to the object storage at target, an s3://bucket/prefix
+// URL. Any S3-compatible storage works with S3_ENDPOINT, e.g. Cloudflare R2, or Google Cloud Storage with HMAC keys.
+// Objects whose MD5 already matches are skipped, and objects under plugins/ without a local file are deleted when
+// prune is set. The masters are uploaded after every other file so that clients never see a master pointing at
+// objects not uploaded yet.
+func PublishTree(cfg *Config, target string, prune bool) error {
+	client, err := NewS3Client(cfg)
+	if err != nil {
+		return err
+	}
+
+	bucket, prefix, err := ParseS3URL(target)
+	if err != nil {
+		return err
+	}
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	objects, err := client.ListObjects(bucket, prefix+"plugins/")
+	if err != nil {
+		return err
+	}
+
+	remote := map[string]string{}
+	for _, object := range objects {
+		remote[object.Key] = object.ETag
+	}
+
+	excluded := slices.Clone(internalStateFiles)
+	if cfg.StatsCache != "" {
+		excluded = append(excluded, filepath.ToSlash(filepath.Clean(cfg.StatsCache)))
+	}
+
+	var paths, masters []string
+	err = filepath.WalkDir("plugins", func(p string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || slices.Contains(excluded, filepath.ToSlash(p)) {
+			return err
+		}
+
+		if isMasterFile(p) {
+			masters = append(masters, p)
+		} else {
+			paths = append(paths, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// robots.txt is only looked up at the root of the host, next to the plugins directory.
+	if _, err = os.Stat(robotsPath); err == nil {
+		rootObjects, err := client.ListObjects(bucket, prefix+robotsPath)
+		if err != nil {
+			return err
+		}
+		for _, object := range rootObjects {
+			if object.Key == prefix+robotsPath {
+				remote[object.Key] = object.ETag
+			}
+		}
+
+		paths = append(paths, robotsPath)
+	}
+
+	var uploaded int
+	for _, p := range append(paths, masters...) {
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+
+		key := prefix + filepath.ToSlash(p)
+		etag, ok := remote[key]
+		delete(remote, key)
+
+		sum := md5.Sum(content)
+		if ok && etag == hex.EncodeToString(sum[:]) {
+			continue
+		}
+
+		contentType := mime.TypeByExtension(path.Ext(key))
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		if err = client.PutObject(bucket, key, content, contentType); err != nil {
+			return err
+		}
+
+		uploaded++
+	}
+
+	var deleted int
+	if prune {
+		for key := range remote {
+			if err = client.DeleteObject(bucket, key); err != nil {
+				return err
+			}
+
+			deleted++
+		}
+	}
+
+	log.Printf("published to %s: %d uploaded, %d deleted", target, uploaded, deleted)
+	return nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// cloudflarePurgeBatch is the number of URLs Cloudflare accepts in a single purge request.
+const cloudflarePurgeBatch = 30
+
+// StaleURLs lists the URLs of the files a run has rewritten: the masters when a manifest changed, and the zips and
+// endpoints of the changed plugins. Updated download counts alone do not purge anything and reach clients when the
+// cached masters expire.
+func StaleURLs(previous, current []*PluginManifest, changed []string, domain string) ([]string, error) {
+	diff, err := DiffMasters(previous, current, contentFieldNames())
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	if len(diff.Added) > 0 || len(diff.Removed) > 0 || len(diff.Changes) > 0 {
+		paths = append(paths, "plugins/master.json", "plugins/master.json.gz", "plugins/master.json.br", "plugins/master.json.minisig", "plugins/master.json.sha256", "plugins/master.json.etag", "plugins/master.pretty.json", "plugins/stable.json", "plugins/testing.json", "plugins/search.json")
+
+		localized, err := filepath.Glob(filepath.Join("plugins", "i18n", "master.*.json"))
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range localized {
+			paths = append(paths, filepath.ToSlash(path))
+		}
+	}
+
+	for _, directory := range changedPaths(changed) {
+		paths = append(paths, directory+"/latest.zip")
+	}
+	for _, name := range changed {
+		paths = append(paths, fmt.Sprintf("plugins/api/%s.json", name))
+	}
+
+	var urls []string
+	for _, path := range paths {
+		if _, err := os.Stat(filepath.FromSlash(path)); err == nil {
+			urls = append(urls, fmt.Sprintf("https://%s/%s", domain, path))
+		}
+	}
+
+	return urls, nil
+}
+
+// PurgeCloudflareCache purges the URLs from the Cloudflare cache of the zone, so that clients stop receiving stale
+// files from the CDN.
+func PurgeCloudflareCache(cfg *Config, urls []string) error {
+	if cfg.CloudflareAPIToken == "" || cfg.CloudflareZoneID == "" {
+		return errors.New("CLOUDFLARE_API_TOKEN and CLOUDFLARE_ZONE_ID are required to purge the cache")
+	}
+
+	for start := 0; start < len(urls); start += cloudflarePurgeBatch {
+		payload, err := json.Marshal(map[string][]string{"files": urls[start:min(start+cloudflarePurgeBatch, len(urls))]})
+		if err != nil {
+			return err
+		}
+
+		request, err := http.NewRequest(http.MethodPost, fmt.Sprintf("https://api.cloudflare.com/client/v4/zones/%s/purge_cache", cfg.CloudflareZoneID), bytes.NewReader(payload))
+		if err != nil {
+			return err
+		}
+
+		request.Header.Set("User-Agent", userAgent)
+		request.Header.Set("Content-Type", "application/json")
+		request.Header.Set("Authorization", "Bearer "+cfg.CloudflareAPIToken)
+
+		response, err := httpClient.Do(request)
+		if err != nil {
+			return err
+		}
+
+		var result struct {
+			Success bool `json:"success"`
+			Errors  []struct {
+				Message string `json:"message"`
+			} `json:"errors"`
+		}
+		err = json.NewDecoder(response.Body).Decode(&result)
+		response.Body.Close()
+		if err != nil {
+			return fmt.Errorf("unexpected Cloudflare response: %s: %w", response.Status, err)
+		}
+		if !result.Success {
+			if len(result.Errors) > 0 {
+				return fmt.Errorf("failed to purge: %s", result.Errors[0].Message)
+			}
+
+			return fmt.Errorf("unexpected Cloudflare status: %s", response.Status)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+type githubRelease struct {
+	Assets []struct {
+		Name          string `json:"name"`
+		DownloadCount int64  `json:"download_count"`
+	} `json:"assets"`
+}
+
+func fetchGitHubReleases(owner, repo, token string) ([]*githubRelease, error) {
+	var releases []*githubRelease
+	for page := 1; ; page++ {
+		var chunk []*githubRelease
+		status, err := githubRequest(fmt.Sprintf("/repos/%s/%s/releases?per_page=100&page=%d", owner, repo, page), token, &chunk)
+		if err != nil {
+			return nil, &retryableError{err}
+		}
+		if status != http.StatusOK {
+			err = fmt.Errorf("unexpected GitHub status for %s/%s: %d", owner, repo, status)
+			if status == http.StatusTooManyRequests || status >= http.StatusInternalServerError {
+				return nil, &retryableError{err}
+			}
+
+			return nil, err
+		}
+
+		releases = append(releases, chunk...)
+		if len(chunk) < 100 {
+			return releases, nil
+		}
+	}
+}
+
+// GitHubReleaseStatsProvider counts downloads of the plugin zips mirrored as GitHub release assets.
+type GitHubReleaseStatsProvider struct {
+	cfg *Config
+}
+
+func (p *GitHubReleaseStatsProvider) FetchDownloadStatistics(repositories map[string]string) (map[string]int64, error) {
+	return FetchGitHubReleaseStatistics(p.cfg, repositories)
+}
+
+// FetchGitHubReleaseStatistics sums the download counts of the zip assets attached to the GitHub releases of each
+// plugin's RepoUrl. When several plugins share a repository, only the assets whose name contains the InternalName
+// are counted for each of them.
+func FetchGitHubReleaseStatistics(cfg *Config, repositories map[string]string) (map[string]int64, error) {
+	plugins := map[string][]string{}
+	for name, repoURL := range repositories {
+		owner, repo, ok := parseGitHubRepository(repoURL)
+		if !ok {
+			continue
+		}
+
+		key := strings.ToLower(owner + "/" + repo)
+		plugins[key] = append(plugins[key], name)
+	}
+
+	downloads := map[string]int64{}
+	for key, names := range plugins {
+		owner, repo, _ := strings.Cut(key, "/")
+		releases, err := withRetry(cfg.StatsRetries, cfg.StatsRetryDelay, func() ([]*githubRelease, error) {
+			return fetchGitHubReleases(owner, repo, cfg.GitHubToken)
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, release := range releases {
+			for _, asset := range release.Assets {
+				assetName := strings.ToLower(asset.Name)
+				if !strings.HasSuffix(assetName, ".zip") {
+					continue
+				}
+
+				for _, name := range names {
+					if len(names) == 1 || strings.Contains(assetName, strings.ToLower(name)) {
+						downloads[name] += asset.DownloadCount
+					}
+				}
+			}
+		}
+	}
+
+	return downloads, nil
+}
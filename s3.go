@@ -0,0 +1,235 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// S3Client is a minimal S3 client signing requests with AWS Signature Version 4. It uses path-style URLs so that it
+// also works with S3-compatible storages such as R2 or MinIO configured by S3_ENDPOINT.
+type S3Client struct {
+	endpoint     string
+	region       string
+	accessKey    string
+	secretKey    string
+	sessionToken string
+}
+
+func NewS3Client(cfg *Config) (*S3Client, error) {
+	if cfg.AWSAccessKeyID == "" || cfg.AWSSecretAccessKey == "" {
+		return nil, errors.New("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY are required for S3")
+	}
+
+	endpoint := cfg.S3Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", cfg.AWSRegion)
+	}
+
+	return &S3Client{
+		endpoint:     strings.TrimSuffix(endpoint, "/"),
+		region:       cfg.AWSRegion,
+		accessKey:    cfg.AWSAccessKeyID,
+		secretKey:    cfg.AWSSecretAccessKey,
+		sessionToken: cfg.AWSSessionToken,
+	}, nil
+}
+
+// ParseS3URL splits s3://bucket/key into the bucket and the key.
+func ParseS3URL(s string) (string, string, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return "", "", err
+	}
+	if u.Scheme != "s3" || u.Host == "" {
+		return "", "", fmt.Errorf("invalid S3 URL: %s", s)
+	}
+
+	return u.Host, strings.TrimPrefix(u.Path, "/"), nil
+}
+
+// s3EscapePath escapes each segment of the path as required by the canonical request of Signature Version 4.
+func s3EscapePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = strings.ReplaceAll(url.PathEscape(segment), "+", "%2B")
+	}
+
+	return strings.Join(segments, "/")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func (c *S3Client) sign(request *http.Request, payloadHash string, now time.Time) {
+	timestamp := now.UTC().Format("20060102T150405Z")
+	date := timestamp[:8]
+
+	request.Header.Set("X-Amz-Date", timestamp)
+	request.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if c.sessionToken != "" {
+		request.Header.Set("X-Amz-Security-Token", c.sessionToken)
+	}
+
+	names := []string{"host"}
+	for name := range request.Header {
+		lower := strings.ToLower(name)
+		if strings.HasPrefix(lower, "x-amz-") || lower == "content-type" || lower == "content-md5" {
+			names = append(names, lower)
+		}
+	}
+	sort.Strings(names)
+
+	var headers strings.Builder
+	for _, name := range names {
+		value := request.Host
+		if name != "host" {
+			value = strings.TrimSpace(request.Header.Get(name))
+		}
+		fmt.Fprintf(&headers, "%s:%s\n", name, value)
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	query := request.URL.Query()
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var pairs []string
+	for _, key := range keys {
+		for _, value := range query[key] {
+			pairs = append(pairs, strings.ReplaceAll(url.QueryEscape(key), "+", "%20")+"="+strings.ReplaceAll(url.QueryEscape(value), "+", "%20"))
+		}
+	}
+
+	canonicalRequest := strings.Join([]string{
+		request.Method,
+		request.URL.EscapedPath(),
+		strings.Join(pairs, "&"),
+		headers.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := fmt.Sprintf("%s/%s/s3/aws4_request", date, c.region)
+	canonicalHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", timestamp, scope, hex.EncodeToString(canonicalHash[:])}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+c.secretKey), date)
+	key = hmacSHA256(key, c.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	request.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", c.accessKey, scope, signedHeaders, signature))
+}
+
+func (c *S3Client) do(method, bucket, key string, query url.Values, body []byte, headers map[string]string) ([]byte, error) {
+	u, err := url.Parse(fmt.Sprintf("%s/%s/%s", c.endpoint, bucket, s3EscapePath(key)))
+	if err != nil {
+		return nil, err
+	}
+	u.RawQuery = strings.ReplaceAll(query.Encode(), "+", "%20")
+
+	request, err := http.NewRequest(method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	request.Header.Set("User-Agent", userAgent)
+	for name, value := range headers {
+		request.Header.Set(name, value)
+	}
+
+	sum := sha256.Sum256(body)
+	c.sign(request, hex.EncodeToString(sum[:]), time.Now())
+
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+
+	defer response.Body.Close()
+	content, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode >= 300 {
+		return nil, fmt.Errorf("unexpected S3 status for %s/%s: %s: %s", bucket, key, response.Status, bytes.TrimSpace(content))
+	}
+
+	return content, nil
+}
+
+func (c *S3Client) GetObject(bucket, key string) ([]byte, error) {
+	return c.do(http.MethodGet, bucket, key, nil, nil, nil)
+}
+
+// S3Object is an entry of a bucket listing. ETag is the MD5 of the content for objects uploaded in a single part.
+type S3Object struct {
+	Key  string
+	ETag string
+}
+
+// ListObjects returns all objects under the prefix.
+func (c *S3Client) ListObjects(bucket, prefix string) ([]*S3Object, error) {
+	var objects []*S3Object
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		content, err := c.do(http.MethodGet, bucket, "", query, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var result struct {
+			Contents []struct {
+				Key  string `xml:"Key"`
+				ETag string `xml:"ETag"`
+			} `xml:"Contents"`
+			IsTruncated           bool   `xml:"IsTruncated"`
+			NextContinuationToken string `xml:"NextContinuationToken"`
+		}
+		if err = xml.Unmarshal(content, &result); err != nil {
+			return nil, err
+		}
+
+		for _, object := range result.Contents {
+			objects = append(objects, &S3Object{Key: object.Key, ETag: strings.Trim(object.ETag, `"`)})
+		}
+		if !result.IsTruncated {
+			return objects, nil
+		}
+
+		token = result.NextContinuationToken
+	}
+}
+
+func (c *S3Client) PutObject(bucket, key string, content []byte, contentType string) error {
+	_, err := c.do(http.MethodPut, bucket, key, nil, content, map[string]string{"Content-Type": contentType})
+	return err
+}
+
+func (c *S3Client) DeleteObject(bucket, key string) error {
+	_, err := c.do(http.MethodDelete, bucket, key, nil, nil, nil)
+	return err
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+type depsJSON struct {
+	Libraries map[string]struct {
+		Type   string `json:"type"`
+		SHA512 string `json:"sha512"`
+	} `json:"libraries"`
+}
+
+type Dependency struct {
+	Name    string `json:"Name"`
+	Version string `json:"Version"`
+	Type    string `json:"Type"`
+	SHA512  string `json:"Sha512,omitempty"`
+}
+
+// ReadDependencies lists the libraries recorded in <InternalName>.deps.json inside latest.zip.
+func ReadDependencies(directory, name string) ([]*Dependency, error) {
+	archive, err := OpenPluginZip(directory)
+	if err != nil || archive == nil {
+		return nil, err
+	}
+	defer archive.Close()
+
+	file := findZipFile(&archive.Reader, name+".deps.json")
+	if file == nil {
+		return nil, nil
+	}
+
+	content, err := readZipFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var deps depsJSON
+	if err = json.Unmarshal(content, &deps); err != nil {
+		return nil, err
+	}
+
+	dependencies := []*Dependency{}
+	for key, library := range deps.Libraries {
+		libraryName, version, _ := strings.Cut(key, "/")
+		if libraryName == name && library.Type == "project" {
+			continue
+		}
+
+		dependencies = append(dependencies, &Dependency{
+			Name:    libraryName,
+			Version: version,
+			Type:    library.Type,
+			SHA512:  library.SHA512,
+		})
+	}
+
+	sort.Slice(dependencies, func(i, j int) bool {
+		return dependencies[i].Name < dependencies[j].Name
+	})
+
+	return dependencies, nil
+}
+
+// WriteDependencyInventory writes dependencies.json next to latest.zip when the zip ships a deps.json.
+func WriteDependencyInventory(directory, name string) error {
+	dependencies, err := ReadDependencies(directory, name)
+	if err != nil || dependencies == nil {
+		return err
+	}
+
+	return writeJSON(filepath.Join(directory, "dependencies.json"), dependencies)
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+)
+
+type JSONSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Type                 string                 `json:"type"`
+	Format               string                 `json:"format,omitempty"`
+	Items                *JSONSchema            `json:"items,omitempty"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties *bool                  `json:"additionalProperties,omitempty"`
+}
+
+func isURLField(field reflect.StructField) bool {
+	return strings.HasSuffix(field.Name, "URL") || strings.HasSuffix(field.Name, "URLs") || strings.HasPrefix(field.Name, "DownloadLink")
+}
+
+func schemaForType(t reflect.Type) *JSONSchema {
+	switch t.Kind() {
+	case reflect.String:
+		return &JSONSchema{Type: "string"}
+	case reflect.Bool:
+		return &JSONSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &JSONSchema{Type: "integer"}
+	case reflect.Slice:
+		return &JSONSchema{Type: "array", Items: schemaForType(t.Elem())}
+	default:
+		return &JSONSchema{Type: "object"}
+	}
+}
+
+func GenerateManifestSchema() *JSONSchema {
+	additional := true
+	schema := &JSONSchema{
+		Schema:               "https://json-schema.org/draft/2020-12/schema",
+		Title:                "Dalamud plugin manifest",
+		Type:                 "object",
+		Properties:           map[string]*JSONSchema{},
+		AdditionalProperties: &additional,
+	}
+
+	t := reflect.TypeOf(PluginManifest{})
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+
+		property := schemaForType(field.Type)
+		if isURLField(field) {
+			if property.Items != nil {
+				property.Items.Format = "uri"
+			} else {
+				property.Format = "uri"
+			}
+		}
+
+		schema.Properties[name] = property
+		if !strings.Contains(options, "omitempty") {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+
+	return schema
+}
+
+func WriteManifestSchema(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(GenerateManifestSchema())
+}
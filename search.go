@@ -0,0 +1,48 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+type SearchIndexEntry struct {
+	Name         string   `json:"Name"`
+	InternalName string   `json:"InternalName"`
+	Punchline    string   `json:"Punchline,omitempty"`
+	Tags         []string `json:"Tags,omitempty"`
+}
+
+// DumpSearchIndex writes plugins/search.json, a minified index of the listed plugins for client-side search widgets.
+// Tags and CategoryTags are merged into lowercase tags.
+func DumpSearchIndex(manifests []*PluginManifest) error {
+	index := []*SearchIndexEntry{}
+	for _, manifest := range manifests {
+		if manifest.IsHide {
+			continue
+		}
+
+		var tags []string
+		for _, tag := range append(slices.Clone(manifest.Tags), manifest.CategoryTags...) {
+			if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" && !slices.Contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+
+		index = append(index, &SearchIndexEntry{
+			Name:         manifest.Name,
+			InternalName: manifest.InternalName,
+			Punchline:    manifest.Punchline,
+			Tags:         tags,
+		})
+	}
+
+	content, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join("plugins", "search.json"), content, 0644)
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Signatures are written in the minisign format using the legacy (non-prehashed) Ed25519 algorithm, so they can
+// be verified with `minisign -V -P <public key> -m <file>`.
+const signatureAlgorithm = "Ed"
+
+type Signer struct {
+	key   ed25519.PrivateKey
+	keyID [8]byte
+}
+
+// LoadSigner decodes a base64-encoded Ed25519 seed or private key. It returns nil when no key is configured.
+func LoadSigner(encoded string) (*Signer, error) {
+	if encoded == "" {
+		return nil, nil
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode signing key: %w", err)
+	}
+
+	var key ed25519.PrivateKey
+	switch len(raw) {
+	case ed25519.SeedSize:
+		key = ed25519.NewKeyFromSeed(raw)
+	case ed25519.PrivateKeySize:
+		key = ed25519.PrivateKey(raw)
+	default:
+		return nil, fmt.Errorf("invalid signing key length: %d", len(raw))
+	}
+
+	signer := &Signer{key: key}
+	sum := sha256.Sum256(key.Public().(ed25519.PublicKey))
+	copy(signer.keyID[:], sum[:8])
+
+	return signer, nil
+}
+
+func (s *Signer) PublicKey() string {
+	payload := append([]byte(signatureAlgorithm), s.keyID[:]...)
+	payload = append(payload, s.key.Public().(ed25519.PublicKey)...)
+
+	return fmt.Sprintf("untrusted comment: minisign public key %016X\n%s\n", binary.LittleEndian.Uint64(s.keyID[:]), base64.StdEncoding.EncodeToString(payload))
+}
+
+func (s *Signer) Sign(content []byte, filename string) string {
+	signature := ed25519.Sign(s.key, content)
+
+	payload := append([]byte(signatureAlgorithm), s.keyID[:]...)
+	payload = append(payload, signature...)
+
+	trustedComment := fmt.Sprintf("timestamp:%d\tfile:%s", time.Now().Unix(), filename)
+	globalSignature := ed25519.Sign(s.key, append(signature, []byte(trustedComment)...))
+
+	return fmt.Sprintf(
+		"untrusted comment: signature from divination-plugin-master-generator\n%s\ntrusted comment: %s\n%s\n",
+		base64.StdEncoding.EncodeToString(payload),
+		trustedComment,
+		base64.StdEncoding.EncodeToString(globalSignature),
+	)
+}
+
+// SignFile writes a detached signature of the file to <path>.minisig.
+func (s *Signer) SignFile(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path+".minisig", []byte(s.Sign(content, filepath.Base(path))), 0644)
+}
+
+// SignPluginZip signs latest.zip in the directory, reporting whether the zip exists.
+func (s *Signer) SignPluginZip(directory string) (bool, error) {
+	path := filepath.Join(directory, "latest.zip")
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return false, nil
+	}
+
+	return true, s.SignFile(path)
+}
+
+func (s *Signer) WritePublicKey() error {
+	return os.WriteFile(filepath.Join("plugins", "minisign.pub"), []byte(s.PublicKey()), 0644)
+}
+
+func keygenCommand(w io.Writer) error {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return err
+	}
+
+	seed := base64.StdEncoding.EncodeToString(key.Seed())
+	signer, err := LoadSigner(seed)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "SIGNING_KEY=%s\n\n%s", seed, signer.PublicKey())
+	return err
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+type sitemapURLSet struct {
+	XMLName xml.Name      `xml:"urlset"`
+	Xmlns   string        `xml:"xmlns,attr"`
+	URLs    []*sitemapURL `xml:"url"`
+}
+
+// robotsPath is robots.txt at the root of the hosting tree. PublishTree uploads it along with the plugins directory.
+const robotsPath = "robots.txt"
+
+// DumpSitemap writes plugins/catalog/sitemap.xml listing the catalog pages, and makes sure robots.txt at the root of
+// the hosting tree points to it.
+func DumpSitemap(manifests []*PluginManifest, domain string) error {
+	base := fmt.Sprintf("https://%s/plugins/catalog/", domain)
+
+	urlSet := &sitemapURLSet{Xmlns: "http://www.sitemaps.org/schemas/sitemap/0.9"}
+	var latest int64
+	for _, manifest := range manifests {
+		if manifest.IsHide {
+			continue
+		}
+
+		entry := &sitemapURL{Loc: base + url.PathEscape(manifest.InternalName) + ".html"}
+		if manifest.LastUpdate > 0 {
+			entry.LastMod = time.Unix(manifest.LastUpdate, 0).UTC().Format("2006-01-02")
+		}
+		urlSet.URLs = append(urlSet.URLs, entry)
+		latest = max(latest, manifest.LastUpdate)
+	}
+
+	index := &sitemapURL{Loc: base + "index.html"}
+	if latest > 0 {
+		index.LastMod = time.Unix(latest, 0).UTC().Format("2006-01-02")
+	}
+	urlSet.URLs = append([]*sitemapURL{index}, urlSet.URLs...)
+
+	content, err := xml.MarshalIndent(urlSet, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = os.WriteFile(filepath.Join("plugins", "catalog", "sitemap.xml"), append([]byte(xml.Header), content...), 0644); err != nil {
+		return err
+	}
+
+	return addRobotsSitemap(robotsPath, base+"sitemap.xml")
+}
+
+// addRobotsSitemap adds a Sitemap entry to robots.txt, keeping the rules of an existing file.
+func addRobotsSitemap(path, sitemap string) error {
+	entry := "Sitemap: " + sitemap
+
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return os.WriteFile(path, []byte("User-agent: *\nAllow: /\n\n"+entry+"\n"), 0644)
+	}
+	if err != nil {
+		return err
+	}
+
+	lines := strings.Split(strings.TrimRight(string(content), "\n"), "\n")
+	if slices.Contains(lines, entry) {
+		return nil
+	}
+
+	return os.WriteFile(path, []byte(strings.Join(append(lines, entry), "\n")+"\n"), 0644)
+}
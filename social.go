@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Maximum post lengths of Bluesky (in graphemes, approximated by runes) and Mastodon.
+const (
+	maxBlueskyPostLength  = 300
+	maxMastodonPostLength = 500
+)
+
+// formatAnnouncement builds a short announcement of a stable release which fits in limit runes. The punchline is
+// truncated first so that the repository link is always kept.
+func formatAnnouncement(update *PluginUpdate, limit int) string {
+	headline := fmt.Sprintf("%s %s has been released!", update.Manifest.Name, update.Version)
+	if update.IsNew() {
+		headline = fmt.Sprintf("New plugin: %s %s", update.Manifest.Name, update.Version)
+	}
+
+	link := update.Manifest.RepoURL
+	budget := limit - len([]rune(headline)) - len([]rune(link)) - 4
+	lines := []string{headline}
+	if update.Manifest.Punchline != "" && budget > 1 {
+		lines = append(lines, truncateText(update.Manifest.Punchline, budget))
+	}
+	if link != "" {
+		lines = append(lines, link)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func stableUpdates(updates []*PluginUpdate) []*PluginUpdate {
+	var stable []*PluginUpdate
+	for _, update := range updates {
+		if update.Channel == "stable" {
+			stable = append(stable, update)
+		}
+	}
+
+	return stable
+}
+
+func requestJSON(method, u, token string, payload, v any) error {
+	var body bytes.Buffer
+	if payload != nil {
+		if err := json.NewEncoder(&body).Encode(payload); err != nil {
+			return err
+		}
+	}
+
+	request, err := http.NewRequest(method, u, &body)
+	if err != nil {
+		return err
+	}
+
+	request.Header.Set("User-Agent", userAgent)
+	request.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+
+	defer response.Body.Close()
+	if response.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status from %s: %s", u, response.Status)
+	}
+	if v == nil {
+		return nil
+	}
+
+	return json.NewDecoder(response.Body).Decode(v)
+}
+
+type MastodonNotifier struct {
+	server      string
+	accessToken string
+}
+
+func (n *MastodonNotifier) Notify(updates []*PluginUpdate) error {
+	endpoint, err := url.JoinPath(n.server, "/api/v1/statuses")
+	if err != nil {
+		return err
+	}
+
+	for _, update := range stableUpdates(updates) {
+		status := map[string]string{
+			"status":     formatAnnouncement(update, maxMastodonPostLength),
+			"visibility": "public",
+		}
+		if err = requestJSON(http.MethodPost, endpoint, n.accessToken, status, nil); err != nil {
+			return fmt.Errorf("%s: failed to post to Mastodon: %w", update.Manifest.InternalName, err)
+		}
+	}
+
+	return nil
+}
+
+type BlueskyNotifier struct {
+	service    string
+	identifier string
+	password   string
+}
+
+type blueskyFacet struct {
+	Index struct {
+		ByteStart int `json:"byteStart"`
+		ByteEnd   int `json:"byteEnd"`
+	} `json:"index"`
+	Features []map[string]string `json:"features"`
+}
+
+func (n *BlueskyNotifier) Notify(updates []*PluginUpdate) error {
+	updates = stableUpdates(updates)
+	if len(updates) == 0 {
+		return nil
+	}
+
+	var session struct {
+		AccessJwt string `json:"accessJwt"`
+		DID       string `json:"did"`
+	}
+	credentials := map[string]string{"identifier": n.identifier, "password": n.password}
+	if err := requestJSON(http.MethodPost, n.service+"/xrpc/com.atproto.server.createSession", "", credentials, &session); err != nil {
+		return fmt.Errorf("failed to log in to Bluesky: %w", err)
+	}
+
+	for _, update := range updates {
+		text := formatAnnouncement(update, maxBlueskyPostLength)
+		record := map[string]any{
+			"$type":     "app.bsky.feed.post",
+			"text":      text,
+			"createdAt": time.Now().UTC().Format(time.RFC3339),
+		}
+
+		// Links are only clickable on Bluesky when they are annotated with a facet.
+		if link := update.Manifest.RepoURL; link != "" {
+			if start := strings.LastIndex(text, link); start >= 0 {
+				facet := &blueskyFacet{Features: []map[string]string{{"$type": "app.bsky.richtext.facet#link", "uri": link}}}
+				facet.Index.ByteStart = start
+				facet.Index.ByteEnd = start + len(link)
+				record["facets"] = []*blueskyFacet{facet}
+			}
+		}
+
+		payload := map[string]any{
+			"repo":       session.DID,
+			"collection": "app.bsky.feed.post",
+			"record":     record,
+		}
+		if err := requestJSON(http.MethodPost, n.service+"/xrpc/com.atproto.repo.createRecord", session.AccessJwt, payload, nil); err != nil {
+			return fmt.Errorf("%s: failed to post to Bluesky: %w", update.Manifest.InternalName, err)
+		}
+	}
+
+	return nil
+}
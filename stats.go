@@ -0,0 +1,399 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// retryableError marks failures which may succeed when the request is sent again.
+type retryableError struct {
+	err error
+}
+
+func (e *retryableError) Error() string {
+	return e.err.Error()
+}
+
+func (e *retryableError) Unwrap() error {
+	return e.err
+}
+
+// withRetry calls f until it succeeds, returns a non-retryable error or the retries are exhausted. The delay between
+// attempts doubles every time and is randomized by up to 50% to avoid synchronized retries.
+func withRetry[T any](retries int, delay time.Duration, f func() (T, error)) (T, error) {
+	for attempt := 0; ; attempt++ {
+		result, err := f()
+		if err == nil {
+			return result, nil
+		}
+
+		if _, ok := err.(*retryableError); !ok || attempt >= retries {
+			return result, err
+		}
+
+		wait := delay << attempt
+		wait += time.Duration(rand.Int64N(int64(wait)/2 + 1))
+		log.Printf("retrying in %s: %v", wait.Round(time.Millisecond), err)
+		time.Sleep(wait)
+	}
+}
+
+// setStatsAuthorization authenticates the request with STATS_TOKEN as a bearer token, or with STATS_USERNAME and
+// STATS_PASSWORD as basic auth.
+func setStatsAuthorization(request *http.Request, cfg *Config) {
+	switch {
+	case cfg.StatsToken != "":
+		request.Header.Set("Authorization", "Bearer "+cfg.StatsToken)
+	case cfg.StatsUsername != "":
+		request.SetBasicAuth(cfg.StatsUsername, cfg.StatsPassword)
+	}
+}
+
+func fetchDownloadStatistics(url string, cfg *Config) (map[string]int64, error) {
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	request.Header.Set("User-Agent", userAgent)
+	setStatsAuthorization(request, cfg)
+
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return nil, &retryableError{err}
+	}
+
+	defer response.Body.Close()
+	if response.StatusCode == http.StatusTooManyRequests || response.StatusCode >= 500 {
+		return nil, &retryableError{fmt.Errorf("unexpected status: %s", response.Status)}
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status: %s", response.Status)
+	}
+
+	content, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, &retryableError{err}
+	}
+
+	return ParseDownloadStatistics(content, response.Header.Get("Content-Type"), cfg)
+}
+
+// StatsURLs expands STATS_URL, a comma-separated list of endpoints of the mirrors serving the repository. Each of
+// them is either a full URL or a path on the hosting domain and may reference {{ .HostingDomain }}.
+func StatsURLs(cfg *Config) ([]string, error) {
+	var urls []string
+	for _, u := range cfg.StatsURLs {
+		url, err := expandTemplate(u, &ManifestTemplateData{HostingDomain: cfg.HostingDomain})
+		if err != nil {
+			return nil, fmt.Errorf("failed to expand STATS_URL: %w", err)
+		}
+
+		if strings.HasPrefix(url, "/") {
+			url = fmt.Sprintf("https://%s%s", cfg.HostingDomain, url)
+		}
+		urls = append(urls, url)
+	}
+
+	return urls, nil
+}
+
+// StatsProvider is a backend counting plugin downloads. Counts are keyed by InternalName or by a per-channel key such
+// as "stable/<InternalName>", which are resolved by LoadDownloadStatistics.
+type StatsProvider interface {
+	// FetchDownloadStatistics returns the download counts. repositories maps InternalNames to their RepoUrl for
+	// providers reading counts from the plugin repositories.
+	FetchDownloadStatistics(repositories map[string]string) (map[string]int64, error)
+}
+
+// statsProviders maps the values of STATS_PROVIDER to the constructors of their providers.
+var statsProviders = map[string]func(cfg *Config) (StatsProvider, error){
+	"http-json":  NewHTTPStatsProvider,
+	"cloudflare": func(cfg *Config) (StatsProvider, error) { return &CloudflareStatsProvider{cfg: cfg}, nil },
+	"logs":       func(cfg *Config) (StatsProvider, error) { return &AccessLogStatsProvider{cfg: cfg}, nil },
+	"github":     func(cfg *Config) (StatsProvider, error) { return &GitHubReleaseStatsProvider{cfg: cfg}, nil },
+	"none":       func(cfg *Config) (StatsProvider, error) { return noStatsProvider{}, nil },
+}
+
+// NewStatsProvider creates the providers listed in STATS_PROVIDER. Several providers, such as "http-json,github" for a
+// repository also mirroring its releases to GitHub, have their counts summed.
+func NewStatsProvider(cfg *Config) (StatsProvider, error) {
+	var providers multiStatsProvider
+	for _, name := range cfg.StatsProviders {
+		constructor, ok := statsProviders[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown stats provider: %s", name)
+		}
+
+		provider, err := constructor(cfg)
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, provider)
+	}
+
+	if len(providers) == 1 {
+		return providers[0], nil
+	}
+	return providers, nil
+}
+
+// multiStatsProvider sums the download counts of several providers per key.
+type multiStatsProvider []StatsProvider
+
+func (p multiStatsProvider) FetchDownloadStatistics(repositories map[string]string) (map[string]int64, error) {
+	downloads := map[string]int64{}
+	for _, provider := range p {
+		statistics, err := provider.FetchDownloadStatistics(repositories)
+		if err != nil {
+			return nil, err
+		}
+
+		for key, count := range statistics {
+			downloads[key] += count
+		}
+	}
+
+	return downloads, nil
+}
+
+// HTTPStatsProvider fetches JSON objects of download counts from STATS_URL, as served by the download counter. The
+// counts of every mirror are summed per key.
+type HTTPStatsProvider struct {
+	cfg  *Config
+	urls []string
+}
+
+func NewHTTPStatsProvider(cfg *Config) (StatsProvider, error) {
+	urls, err := StatsURLs(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &HTTPStatsProvider{cfg: cfg, urls: urls}, nil
+}
+
+func (p *HTTPStatsProvider) FetchDownloadStatistics(map[string]string) (map[string]int64, error) {
+	downloads := map[string]int64{}
+	for _, url := range p.urls {
+		statistics, err := withRetry(p.cfg.StatsRetries, p.cfg.StatsRetryDelay, func() (map[string]int64, error) {
+			return fetchDownloadStatistics(url, p.cfg)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", url, err)
+		}
+
+		for key, count := range statistics {
+			downloads[key] += count
+		}
+	}
+
+	return downloads, nil
+}
+
+// noStatsProvider reports no downloads, for hosts without any way to count them.
+type noStatsProvider struct{}
+
+func (noStatsProvider) FetchDownloadStatistics(map[string]string) (map[string]int64, error) {
+	return map[string]int64{}, nil
+}
+
+type statsCache struct {
+	Timestamp int64            `json:"Timestamp"`
+	Downloads map[string]int64 `json:"Downloads"`
+}
+
+// readStatsCache loads the last successful payload from STATS_CACHE. A cache older than STATS_CACHE_MAX_AGE yields
+// no counts at all rather than increasingly wrong ones.
+func readStatsCache(cfg *Config, now time.Time) (map[string]int64, bool, error) {
+	if cfg.StatsCache == "" {
+		return nil, false, nil
+	}
+
+	var cache *statsCache
+	if err := readOptionalJSON(cfg.StatsCache, &cache); err != nil || cache == nil {
+		return nil, false, err
+	}
+
+	age := now.Sub(time.Unix(cache.Timestamp, 0))
+	if cfg.StatsCacheMaxAge > 0 && age > cfg.StatsCacheMaxAge {
+		log.Printf("download statistics cache is stale (%s old), omitting download counts", age.Round(time.Second))
+		return map[string]int64{}, true, nil
+	}
+
+	log.Printf("using download statistics cached %s ago", age.Round(time.Second))
+	return cache.Downloads, true, nil
+}
+
+func fallbackDownloadStatistics(cfg *Config, err error) (map[string]int64, error) {
+	switch cfg.StatsFallback {
+	case "error":
+		return nil, err
+	case "previous":
+		log.Printf("using download counts of the previous master")
+
+		previous, err := ReadMaster(filepath.Join("plugins", "master.json"))
+		if err != nil {
+			return nil, err
+		}
+
+		downloads := map[string]int64{}
+		for _, manifest := range previous {
+			downloads[manifest.InternalName] = manifest.DownloadCount
+		}
+
+		return downloads, nil
+	case "zero":
+		log.Printf("omitting download counts")
+		return map[string]int64{}, nil
+	default:
+		return nil, fmt.Errorf("unknown stats fallback: %s", cfg.StatsFallback)
+	}
+}
+
+// LoadDownloadStatistics fetches the download counts and resolves their keys to the InternalNames of the plugins.
+// Successful payloads are cached in STATS_CACHE, which is used when a later fetch fails. Without a cache,
+// STATS_FALLBACK decides whether the previous master's counts or no counts are used so that an analytics outage does
+// not block releases.
+func LoadDownloadStatistics(cfg *Config, aliases, repositories map[string]string, names []string) (map[string]int64, error) {
+	provider, err := NewStatsProvider(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	downloads, err := provider.FetchDownloadStatistics(repositories)
+	if err == nil && cfg.StatsCache != "" {
+		if err := writeJSON(cfg.StatsCache, &statsCache{Timestamp: time.Now().Unix(), Downloads: downloads}); err != nil {
+			return nil, err
+		}
+	}
+
+	if err != nil {
+		log.Printf("failed to fetch download statistics: %v", err)
+
+		cached, ok, cacheErr := readStatsCache(cfg, time.Now())
+		if cacheErr != nil {
+			return nil, cacheErr
+		}
+		if !ok {
+			return fallbackDownloadStatistics(cfg, err)
+		}
+
+		downloads = cached
+	}
+
+	return resolveDownloadNames(downloads, aliases, names), nil
+}
+
+// ChannelDownloads holds the download counts of a plugin per channel.
+type ChannelDownloads struct {
+	Stable  int64 `json:"Stable"`
+	Testing int64 `json:"Testing"`
+}
+
+// splitDownloadKey parses a statistics key, which is either an InternalName or a per-path key such as
+// "stable/<InternalName>" or "plugins/testing/<InternalName>/download".
+func splitDownloadKey(key string) (string, string) {
+	parts := strings.Split(strings.Trim(key, "/"), "/")
+	if parts[len(parts)-1] == "download" || parts[len(parts)-1] == "latest.zip" {
+		parts = parts[:len(parts)-1]
+	}
+	if len(parts) < 2 {
+		return "", parts[len(parts)-1]
+	}
+
+	return parts[len(parts)-2], parts[len(parts)-1]
+}
+
+// resolveDownloadNames rewrites statistics keys to InternalNames, following aliases (including renamed plugins) and
+// matching names case-insensitively, so that counts are not lost when a plugin or its statistics key changes.
+func resolveDownloadNames(downloads map[string]int64, aliases map[string]string, names []string) map[string]int64 {
+	folded := map[string]string{}
+	for _, name := range names {
+		folded[strings.ToLower(name)] = name
+	}
+
+	resolved := map[string]int64{}
+	for key, count := range downloads {
+		channel, name := splitDownloadKey(key)
+		if alias, ok := aliases[name]; ok {
+			name = alias
+		} else if n, ok := folded[strings.ToLower(name)]; ok {
+			name = n
+		}
+		if channel != "" {
+			name = channel + "/" + name
+		}
+
+		resolved[name] += count
+	}
+
+	return resolved
+}
+
+// SummarizeDownloads sums the statistics into a total and a per-channel count for each plugin.
+func SummarizeDownloads(downloads map[string]int64) (map[string]int64, map[string]*ChannelDownloads) {
+	totals := map[string]int64{}
+	channels := map[string]*ChannelDownloads{}
+	for key, count := range downloads {
+		channel, name := splitDownloadKey(key)
+		totals[name] += count
+
+		if channel != "stable" && channel != "testing" {
+			continue
+		}
+		if channels[name] == nil {
+			channels[name] = &ChannelDownloads{}
+		}
+		switch channel {
+		case "stable":
+			channels[name].Stable += count
+		case "testing":
+			channels[name].Testing += count
+		}
+	}
+
+	return totals, channels
+}
+
+type DownloadHistoryEntry struct {
+	Timestamp int64            `json:"Timestamp"`
+	Downloads map[string]int64 `json:"Downloads"`
+}
+
+// AppendDownloadHistory appends the current download counts of listed plugins to plugins/stats/history.jsonl.
+func AppendDownloadHistory(manifests []*PluginManifest, now time.Time) error {
+	entry := &DownloadHistoryEntry{Timestamp: now.Unix(), Downloads: map[string]int64{}}
+	for _, manifest := range manifests {
+		if !manifest.IsHide {
+			entry.Downloads[manifest.InternalName] = manifest.DownloadCount
+		}
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join("plugins", "stats", "history.jsonl")
+	if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// detectStatsFormat guesses the format of a statistics payload from its Content-Type, falling back to its content.
+func detectStatsFormat(contentType string, content []byte) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "text/csv":
+		return "csv"
+	case mediaType == "application/openmetrics-text":
+		return "prometheus"
+	case strings.HasSuffix(mediaType, "json"):
+		return "json"
+	}
+
+	trimmed := bytes.TrimSpace(content)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		return "json"
+	case bytes.HasPrefix(trimmed, []byte("#")) || bytes.Contains(trimmed, []byte("{")):
+		return "prometheus"
+	default:
+		return "csv"
+	}
+}
+
+// parseCSVStatistics parses "name,count" rows. A header row is skipped when its count is not a number.
+func parseCSVStatistics(content []byte) (map[string]int64, error) {
+	reader := csv.NewReader(bytes.NewReader(content))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	statistics := map[string]int64{}
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return statistics, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(record) < 2 {
+			return nil, fmt.Errorf("line %d: expected name,count", line)
+		}
+
+		count, err := strconv.ParseInt(strings.TrimSpace(record[1]), 10, 64)
+		if err != nil {
+			if line == 1 {
+				continue
+			}
+
+			return nil, fmt.Errorf("line %d: invalid count: %q", line, record[1])
+		}
+
+		statistics[strings.TrimSpace(record[0])] += count
+	}
+}
+
+var (
+	prometheusSamplePattern = regexp.MustCompile(`^([a-zA-Z_:][a-zA-Z0-9_:]*)(?:\{(.*)\})?\s+(\S+)`)
+	prometheusLabelPattern  = regexp.MustCompile(`([a-zA-Z_][a-zA-Z0-9_]*)\s*=\s*"((?:[^"\\]|\\.)*)"`)
+)
+
+// parsePrometheusStatistics reads the samples of the metric in the Prometheus exposition format. The plugin is taken
+// from the "plugin" (or "name") label and an optional "channel" label selects a per-channel key.
+func parsePrometheusStatistics(content []byte, metric string) (map[string]int64, error) {
+	statistics := map[string]int64{}
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		match := prometheusSamplePattern.FindStringSubmatch(line)
+		if match == nil || match[1] != metric {
+			continue
+		}
+
+		labels := map[string]string{}
+		for _, label := range prometheusLabelPattern.FindAllStringSubmatch(match[2], -1) {
+			value, err := strconv.Unquote(`"` + label[2] + `"`)
+			if err != nil {
+				value = label[2]
+			}
+			labels[label[1]] = value
+		}
+
+		name := labels["plugin"]
+		if name == "" {
+			name = labels["name"]
+		}
+		if name == "" {
+			continue
+		}
+		if channel := labels["channel"]; channel != "" {
+			name = channel + "/" + name
+		}
+
+		value, err := strconv.ParseFloat(match[3], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value of %s: %q", metric, match[3])
+		}
+
+		statistics[name] += int64(math.Round(value))
+	}
+
+	return statistics, nil
+}
+
+// ParseDownloadStatistics decodes a statistics payload in the format of STATS_FORMAT, which is json, csv, prometheus
+// or auto to detect it from the response.
+func ParseDownloadStatistics(content []byte, contentType string, cfg *Config) (map[string]int64, error) {
+	format := cfg.StatsFormat
+	if format == "auto" {
+		format = detectStatsFormat(contentType, content)
+	}
+
+	switch format {
+	case "json":
+		statistics := map[string]int64{}
+		if err := json.Unmarshal(content, &statistics); err != nil {
+			return nil, err
+		}
+
+		return statistics, nil
+	case "csv":
+		return parseCSVStatistics(content)
+	case "prometheus":
+		return parsePrometheusStatistics(content, cfg.StatsMetric)
+	default:
+		return nil, fmt.Errorf("unknown stats format: %s", format)
+	}
+}
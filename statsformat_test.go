@@ -0,0 +1,117 @@
+package main
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestParseDownloadStatistics(t *testing.T) {
+	tests := []struct {
+		name        string
+		format      string
+		contentType string
+		content     string
+		want        map[string]int64
+		wantErr     bool
+	}{
+		{
+			name:    "json",
+			format:  "json",
+			content: `{"Foo": 12, "stable/Bar": 3}`,
+			want:    map[string]int64{"Foo": 12, "stable/Bar": 3},
+		},
+		{
+			name:    "csv with header",
+			format:  "csv",
+			content: "plugin,downloads\nFoo,12\n Bar , 3\nFoo,1\n",
+			want:    map[string]int64{"Foo": 13, "Bar": 3},
+		},
+		{
+			name:    "csv without header",
+			format:  "csv",
+			content: "stable/Foo,5\r\ntesting/Foo,2\r\n",
+			want:    map[string]int64{"stable/Foo": 5, "testing/Foo": 2},
+		},
+		{
+			name:    "csv with an invalid count",
+			format:  "csv",
+			content: "Foo,1\nBar,many\n",
+			wantErr: true,
+		},
+		{
+			name:    "csv with a missing column",
+			format:  "csv",
+			content: "Foo\n",
+			wantErr: true,
+		},
+		{
+			name:   "prometheus",
+			format: "prometheus",
+			content: `# HELP plugin_download_count Downloads per plugin.
+# TYPE plugin_download_count counter
+plugin_download_count{plugin="Foo",channel="stable"} 10
+plugin_download_count{channel="testing", plugin="Foo"} 2.0
+plugin_download_count{name="Bar"} 7 1700000000000
+plugin_download_count{plugin="Baz\"Quoted"} 1
+plugin_download_count{instance="a"} 99
+other_metric{plugin="Foo"} 1000
+`,
+			want: map[string]int64{"stable/Foo": 10, "testing/Foo": 2, "Bar": 7, `Baz"Quoted`: 1},
+		},
+		{
+			name:    "prometheus with an invalid value",
+			format:  "prometheus",
+			content: `plugin_download_count{plugin="Foo"} lots`,
+			wantErr: true,
+		},
+		{
+			name:        "auto from content type",
+			format:      "auto",
+			contentType: "text/csv; charset=utf-8",
+			content:     "Foo,4\n",
+			want:        map[string]int64{"Foo": 4},
+		},
+		{
+			name:    "auto json",
+			format:  "auto",
+			content: ` {"Foo": 4}`,
+			want:    map[string]int64{"Foo": 4},
+		},
+		{
+			name:    "auto prometheus",
+			format:  "auto",
+			content: `plugin_download_count{plugin="Foo"} 4`,
+			want:    map[string]int64{"Foo": 4},
+		},
+		{
+			name:    "auto csv",
+			format:  "auto",
+			content: "Foo,4",
+			want:    map[string]int64{"Foo": 4},
+		},
+		{
+			name:    "unknown format",
+			format:  "xml",
+			content: "<Foo>4</Foo>",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{StatsFormat: tt.format, StatsMetric: "plugin_download_count"}
+			got, err := ParseDownloadStatistics([]byte(tt.content), tt.contentType, cfg)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
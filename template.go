@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+type ManifestTemplateData struct {
+	HostingDomain          string
+	InternalName           string
+	AssemblyVersion        string
+	TestingAssemblyVersion string
+}
+
+func expandTemplate(text string, data *ManifestTemplateData) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+
+	t, err := template.New("").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	var builder strings.Builder
+	if err = t.Execute(&builder, data); err != nil {
+		return "", err
+	}
+
+	return builder.String(), nil
+}
+
+// ExpandManifestTemplates expands the templates in IconUrl, ImageUrls and FeedbackMessage. Free text such as the
+// Description is left alone since it may contain literal braces, e.g. in code samples.
+func ExpandManifestTemplates(manifest *PluginManifest, domain string) error {
+	data := &ManifestTemplateData{
+		HostingDomain:          domain,
+		InternalName:           manifest.InternalName,
+		AssemblyVersion:        manifest.AssemblyVersion,
+		TestingAssemblyVersion: manifest.TestingAssemblyVersion,
+	}
+
+	fields := map[string]*string{
+		"IconUrl":         &manifest.IconURL,
+		"FeedbackMessage": &manifest.FeedbackMessage,
+	}
+	for name, field := range fields {
+		expanded, err := expandTemplate(*field, data)
+		if err != nil {
+			return fmt.Errorf("failed to expand %s: %w", name, err)
+		}
+
+		*field = expanded
+	}
+
+	imageURLs := make([]string, len(manifest.ImageURLs))
+	for i, imageURL := range manifest.ImageURLs {
+		expanded, err := expandTemplate(imageURL, data)
+		if err != nil {
+			return fmt.Errorf("failed to expand ImageUrls: %w", err)
+		}
+
+		imageURLs[i] = expanded
+	}
+	if manifest.ImageURLs != nil {
+		manifest.ImageURLs = imageURLs
+	}
+
+	return nil
+}
@@ -0,0 +1,215 @@
+// Command manifestdiff compares the PluginManifest struct against upstream Dalamud's PluginManifest.cs
+// and reports the fields we are missing. It is invoked via go generate.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const defaultUpstreamURL = "https://raw.githubusercontent.com/goatcorp/Dalamud/master/Dalamud/Plugin/Internal/Types/Manifest/PluginManifest.cs"
+
+type UpstreamField struct {
+	Name     string
+	JSONName string
+	Type     string
+}
+
+var (
+	jsonPropertyPattern = regexp.MustCompile(`\[JsonProperty(?:\("(\w+)"\))?]`)
+	jsonIgnorePattern   = regexp.MustCompile(`\[JsonIgnore]`)
+	propertyPattern     = regexp.MustCompile(`public\s+([\w<>\[\]?,. ]+?)\s+(\w+)\s*{\s*get;`)
+)
+
+func main() {
+	upstream := flag.String("upstream", defaultUpstreamURL, "URL or local path of the upstream PluginManifest.cs")
+	source := flag.String("source", ".", "directory containing the Go PluginManifest struct")
+	strict := flag.Bool("strict", false, "exit with non-zero status when fields are missing")
+	flag.Parse()
+
+	content, err := readUpstream(*upstream)
+	if err != nil {
+		log.Fatalf("failed to read upstream manifest: %v", err)
+	}
+
+	upstreamFields := ParseUpstreamFields(content)
+	if len(upstreamFields) == 0 {
+		log.Fatalf("no fields found in upstream manifest: %s", *upstream)
+	}
+
+	localFields, err := ParseLocalFields(*source)
+	if err != nil {
+		log.Fatalf("failed to parse local manifest: %v", err)
+	}
+
+	var missing []UpstreamField
+	upstreamNames := map[string]bool{}
+	for _, field := range upstreamFields {
+		upstreamNames[field.JSONName] = true
+		if !localFields[field.JSONName] {
+			missing = append(missing, field)
+		}
+	}
+
+	for _, field := range missing {
+		fmt.Printf("missing: %s (%s)\n\t%s %s `json:\"%s,omitempty\"`\n", field.JSONName, field.Type, field.Name, goType(field.Type), field.JSONName)
+	}
+	var extra []string
+	for name := range localFields {
+		if !upstreamNames[name] {
+			extra = append(extra, name)
+		}
+	}
+	sort.Strings(extra)
+	for _, name := range extra {
+		fmt.Printf("not in upstream: %s\n", name)
+	}
+
+	if *strict && len(missing) > 0 {
+		os.Exit(1)
+	}
+}
+
+func readUpstream(location string) (string, error) {
+	if !strings.HasPrefix(location, "http://") && !strings.HasPrefix(location, "https://") {
+		content, err := os.ReadFile(location)
+		return string(content), err
+	}
+
+	response, err := http.Get(location)
+	if err != nil {
+		return "", err
+	}
+
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status: %s", response.Status)
+	}
+
+	content, err := io.ReadAll(response.Body)
+	return string(content), err
+}
+
+func ParseUpstreamFields(content string) []UpstreamField {
+	var fields []UpstreamField
+
+	var jsonName string
+	var ignored bool
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+
+		if jsonIgnorePattern.MatchString(line) {
+			ignored = true
+			continue
+		}
+		if match := jsonPropertyPattern.FindStringSubmatch(line); match != nil {
+			jsonName = match[1]
+			continue
+		}
+
+		match := propertyPattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+
+		if !ignored {
+			name := match[2]
+			if jsonName == "" {
+				jsonName = name
+			}
+
+			fields = append(fields, UpstreamField{
+				Name:     name,
+				JSONName: jsonName,
+				Type:     strings.TrimSuffix(match[1], "?"),
+			})
+		}
+
+		jsonName = ""
+		ignored = false
+	}
+
+	return fields
+}
+
+func ParseLocalFields(directory string) (map[string]bool, error) {
+	paths, err := filepath.Glob(filepath.Join(directory, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+
+	fields := map[string]bool{}
+	fileSet := token.NewFileSet()
+	for _, path := range paths {
+		file, err := parser.ParseFile(fileSet, path, nil, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		ast.Inspect(file, func(node ast.Node) bool {
+			spec, ok := node.(*ast.TypeSpec)
+			if !ok || spec.Name.Name != "PluginManifest" {
+				return true
+			}
+
+			structType, ok := spec.Type.(*ast.StructType)
+			if !ok {
+				return false
+			}
+
+			for _, field := range structType.Fields.List {
+				if field.Tag == nil {
+					continue
+				}
+
+				tag, err := strconv.Unquote(field.Tag.Value)
+				if err != nil {
+					continue
+				}
+
+				name, _, _ := strings.Cut(reflect.StructTag(tag).Get("json"), ",")
+				if name != "" && name != "-" {
+					fields[name] = true
+				}
+			}
+
+			return false
+		})
+	}
+
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("PluginManifest struct not found in %s", directory)
+	}
+
+	return fields, nil
+}
+
+func goType(csType string) string {
+	switch csType {
+	case "string", "Version":
+		return "string"
+	case "bool":
+		return "bool"
+	case "int":
+		return "int"
+	case "long":
+		return "int64"
+	case "List<string>", "IEnumerable<string>", "IReadOnlyList<string>", "string[]":
+		return "[]string"
+	default:
+		return "any"
+	}
+}
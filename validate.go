@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+func lookupManifestField(name string) (reflect.StructField, bool) {
+	t := reflect.TypeOf(PluginManifest{})
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if strings.EqualFold(tag, name) || strings.EqualFold(field.Name, name) {
+			return field, true
+		}
+	}
+
+	return reflect.StructField{}, false
+}
+
+func FindMissingFields(manifest *PluginManifest, fields []string) ([]string, error) {
+	value := reflect.ValueOf(manifest).Elem()
+
+	var missing []string
+	for _, name := range fields {
+		field, ok := lookupManifestField(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown manifest field: %s", name)
+		}
+
+		if value.FieldByIndex(field.Index).IsZero() {
+			missing = append(missing, name)
+		}
+	}
+
+	return missing, nil
+}
+
+func EnforceRequiredFields(manifests []*PluginManifest, fields []string, strict bool) error {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	var failed []string
+	for _, manifest := range manifests {
+		missing, err := FindMissingFields(manifest, fields)
+		if err != nil {
+			return err
+		}
+		if len(missing) == 0 {
+			continue
+		}
+
+		message := fmt.Sprintf("missing required fields: %s", strings.Join(missing, ", "))
+		log.Printf("%s: %s", manifest.InternalName, message)
+		if strict {
+			AnnotateManifest("error", manifest.InternalName, message)
+		} else {
+			AnnotateManifest("warning", manifest.InternalName, message)
+		}
+		failed = append(failed, manifest.InternalName)
+	}
+
+	if strict && len(failed) > 0 {
+		return fmt.Errorf("required fields are missing in %d plugins: %s", len(failed), strings.Join(failed, ", "))
+	}
+
+	return nil
+}
+
+func isValidURL(value string) bool {
+	u, err := url.Parse(value)
+	if err != nil {
+		return false
+	}
+
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+func ValidateManifest(manifest *PluginManifest) []string {
+	var problems []string
+
+	value := reflect.ValueOf(manifest).Elem()
+	t := value.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+
+		v := value.Field(i)
+		if !strings.Contains(options, "omitempty") && v.IsZero() {
+			problems = append(problems, fmt.Sprintf("%s is required", name))
+		}
+
+		if !isURLField(field) {
+			continue
+		}
+
+		var urls []string
+		switch v.Kind() {
+		case reflect.String:
+			urls = []string{v.String()}
+		case reflect.Slice:
+			urls = v.Interface().([]string)
+		}
+		for _, u := range urls {
+			if u != "" && !isValidURL(u) {
+				problems = append(problems, fmt.Sprintf("%s is not a valid URL: %q", name, u))
+			}
+		}
+	}
+
+	// zero is already reported as required
+	if manifest.DalamudApiLevel < 0 {
+		problems = append(problems, fmt.Sprintf("DalamudApiLevel must be positive: %d", manifest.DalamudApiLevel))
+	}
+
+	if manifest.DownloadLinkInstall == "" && manifest.DownloadLinkTesting == "" && !manifest.IsHide {
+		problems = append(problems, "no download link is available")
+	}
+
+	return problems
+}
+
+func ValidateMaster(manifests []*PluginManifest) error {
+	var invalid []string
+	seen := map[string]bool{}
+	for _, manifest := range manifests {
+		problems := ValidateManifest(manifest)
+		if seen[manifest.InternalName] {
+			problems = append(problems, "InternalName is duplicated")
+		}
+		seen[manifest.InternalName] = true
+
+		if len(problems) == 0 {
+			continue
+		}
+
+		for _, problem := range problems {
+			log.Printf("%s: %s", manifest.InternalName, problem)
+			AnnotateManifest("error", manifest.InternalName, problem)
+		}
+		invalid = append(invalid, manifest.InternalName)
+	}
+
+	if len(invalid) > 0 {
+		return fmt.Errorf("master contains %d invalid plugins: %s", len(invalid), strings.Join(invalid, ", "))
+	}
+
+	return nil
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestValidateManifestReportsDalamudApiLevelOnce(t *testing.T) {
+	tests := []struct {
+		name  string
+		level int
+		want  []string
+	}{
+		{name: "zero", level: 0, want: []string{"DalamudApiLevel is required"}},
+		{name: "negative", level: -1, want: []string{"DalamudApiLevel must be positive: -1"}},
+		{name: "positive", level: 10, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, problem := range ValidateManifest(&PluginManifest{DalamudApiLevel: tt.level}) {
+				if strings.HasPrefix(problem, "DalamudApiLevel ") {
+					got = append(got, problem)
+				}
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("DalamudApiLevel problems = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
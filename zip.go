@@ -0,0 +1,186 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+func OpenPluginZip(directory string) (*zip.ReadCloser, error) {
+	p := filepath.Join(directory, "latest.zip")
+	if _, err := os.Stat(p); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	return zip.OpenReader(p)
+}
+
+func findZipFile(archive *zip.Reader, name string) *zip.File {
+	for _, file := range archive.File {
+		if strings.EqualFold(path.Base(file.Name), name) {
+			return file
+		}
+	}
+
+	return nil
+}
+
+func readZipFile(file *zip.File) ([]byte, error) {
+	reader, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return io.ReadAll(reader)
+}
+
+func ReadEmbeddedManifest(directory, name string) (*PluginManifest, error) {
+	archive, err := OpenPluginZip(directory)
+	if err != nil || archive == nil {
+		return nil, err
+	}
+	defer archive.Close()
+
+	file := findZipFile(&archive.Reader, name+".json")
+	if file == nil {
+		return nil, nil
+	}
+
+	content, err := readZipFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest PluginManifest
+	if err = json.Unmarshal(content, &manifest); err != nil {
+		return nil, err
+	}
+
+	return &manifest, nil
+}
+
+func compareEmbeddedManifest(committed, embedded *PluginManifest) []string {
+	var drifts []string
+	if committed.AssemblyVersion != embedded.AssemblyVersion {
+		drifts = append(drifts, fmt.Sprintf("AssemblyVersion %q != %q", committed.AssemblyVersion, embedded.AssemblyVersion))
+	}
+	if committed.DalamudApiLevel != embedded.DalamudApiLevel {
+		drifts = append(drifts, fmt.Sprintf("DalamudApiLevel %d != %d", committed.DalamudApiLevel, embedded.DalamudApiLevel))
+	}
+	if committed.InternalName != embedded.InternalName {
+		drifts = append(drifts, fmt.Sprintf("InternalName %q != %q", committed.InternalName, embedded.InternalName))
+	}
+
+	return drifts
+}
+
+func ApplyEmbeddedManifests(manifests []*PluginManifest, environment, mode string) ([]*PluginManifest, error) {
+	if mode == "" || mode == "off" {
+		return manifests, nil
+	}
+
+	var drifted []string
+	for i, manifest := range manifests {
+		directory := filepath.Join("plugins", environment, manifest.InternalName)
+		embedded, err := ReadEmbeddedManifest(directory, manifest.InternalName)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to read embedded manifest: %w", manifest.InternalName, err)
+		}
+		if embedded == nil {
+			continue
+		}
+
+		switch mode {
+		case "use":
+			manifests[i] = embedded
+		case "check", "strict":
+			drifts := compareEmbeddedManifest(manifest, embedded)
+			if len(drifts) == 0 {
+				continue
+			}
+
+			message := fmt.Sprintf("%s manifest differs from latest.zip: %s", environment, strings.Join(drifts, ", "))
+			log.Printf("%s: %s", manifest.InternalName, message)
+			if mode == "strict" {
+				AnnotateManifest("error", manifest.InternalName, message)
+			} else {
+				AnnotateManifest("warning", manifest.InternalName, message)
+			}
+			drifted = append(drifted, manifest.InternalName)
+		default:
+			return nil, fmt.Errorf("unknown embedded manifest mode: %s", mode)
+		}
+	}
+
+	if mode == "strict" && len(drifted) > 0 {
+		return nil, fmt.Errorf("%s manifests differ from latest.zip: %s", environment, strings.Join(drifted, ", "))
+	}
+
+	return manifests, nil
+}
+
+// detectLastUpdatedFromZip returns the newest modification time recorded in the central directory of latest.zip,
+// which survives git checkouts unlike the filesystem mtime.
+func detectLastUpdatedFromZip(directory string) (int64, error) {
+	archive, err := OpenPluginZip(directory)
+	if err != nil || archive == nil {
+		return 0, err
+	}
+	defer archive.Close()
+
+	var timestamp int64
+	for _, file := range archive.File {
+		timestamp = max(timestamp, file.Modified.Unix())
+	}
+
+	return timestamp, nil
+}
+
+// detectLastUpdatedFromFile reads an explicit Unix timestamp from the last_update file.
+func detectLastUpdatedFromFile(directory string) (int64, error) {
+	content, err := os.ReadFile(filepath.Join(directory, "last_update"))
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseInt(strings.TrimSpace(string(content)), 10, 64)
+}
+
+// ExtractIcon copies images/icon.png from latest.zip into the hosting tree, reporting whether an icon was found.
+func ExtractIcon(directory string) (bool, error) {
+	archive, err := OpenPluginZip(directory)
+	if err != nil || archive == nil {
+		return false, err
+	}
+	defer archive.Close()
+
+	for _, file := range archive.File {
+		if !strings.EqualFold(strings.TrimPrefix(file.Name, "/"), "images/icon.png") {
+			continue
+		}
+
+		content, err := readZipFile(file)
+		if err != nil {
+			return false, err
+		}
+
+		if err = os.MkdirAll(filepath.Join(directory, "images"), 0755); err != nil {
+			return false, err
+		}
+
+		return true, os.WriteFile(filepath.Join(directory, "images", "icon.png"), content, 0644)
+	}
+
+	return false, nil
+}